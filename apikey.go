@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultAPIKeyPrefix     = "key_"
+	DefaultAPIKeyBodyLength = 30
+	MinAPIKeyBodyLength     = 16
+	maxAPIKeyPrefixLength   = 32
+	maxAPIKeyBodyBytes      = 4096
+	// apiKeyChecksumLength is the width of the base62 CRC32 suffix (62^6 > 2^32)
+	apiKeyChecksumLength = 6
+)
+
+// apiKeyPrefixPattern keeps prefixes unambiguous: the key splits at its last underscore
+var apiKeyPrefixPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*_$`)
+
+// apiKeyTailPattern matches the base62 body and checksum after the prefix
+var apiKeyTailPattern = regexp.MustCompile(`^[0-9A-Za-z]+$`)
+
+// APIKeyResponse is the JSON payload returned by /apikey
+type APIKeyResponse struct {
+	Prefix string         `json:"prefix"`
+	Keys   []RandomString `json:"keys"`
+}
+
+// APIKeyVerifyResponse is the JSON payload returned by /apikey/verify
+type APIKeyVerifyResponse struct {
+	Valid  bool   `json:"valid"`
+	Prefix string `json:"prefix,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// validateAPIKeyPrefix checks that prefix is 2–32 characters of letters, digits and
+// underscores, starting with a letter and ending with an underscore.
+func validateAPIKeyPrefix(prefix string) error {
+	if len(prefix) > maxAPIKeyPrefixLength || !apiKeyPrefixPattern.MatchString(prefix) {
+		return fmt.Errorf("prefix must be at most %d letters, digits or underscores, start with a letter and end with '_', got %q",
+			maxAPIKeyPrefixLength, prefix)
+	}
+	return nil
+}
+
+// apiKeyChecksum returns the base62 CRC32 of prefix and body. The prefix is covered so
+// a key moved to another prefix (for example from _test_ to _live_) fails verification.
+func apiKeyChecksum(prefix, body string) string {
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc32.ChecksumIEEE([]byte(prefix+body)))
+	return encodeBase(sum[:], base62Alphabet, apiKeyChecksumLength)
+}
+
+// NewAPIKey returns prefix, a random base62 body of bodyLength characters and a CRC32 checksum
+func NewAPIKey(prefix string, bodyLength int) (string, error) {
+	if err := validateAPIKeyPrefix(prefix); err != nil {
+		return "", err
+	}
+	if bodyLength < MinAPIKeyBodyLength || bodyLength > MaxAllowedLength {
+		return "", fmt.Errorf("len must be between %d and %d", MinAPIKeyBodyLength, MaxAllowedLength)
+	}
+	body := GenerateRandomAlphanumeric(bodyLength)
+	return prefix + body + apiKeyChecksum(prefix, body), nil
+}
+
+// VerifyAPIKey checks the structure and checksum of key without any lookup and returns
+// its prefix. When expectedPrefix is not empty the key must carry exactly that prefix.
+func VerifyAPIKey(key, expectedPrefix string) (string, error) {
+	split := strings.LastIndexByte(key, '_')
+	if split < 0 {
+		return "", fmt.Errorf("key has no prefix")
+	}
+	prefix, tail := key[:split+1], key[split+1:]
+	if err := validateAPIKeyPrefix(prefix); err != nil {
+		return "", err
+	}
+	if expectedPrefix != "" && prefix != expectedPrefix {
+		return prefix, fmt.Errorf("key prefix %q does not match %q", prefix, expectedPrefix)
+	}
+	if len(tail) < MinAPIKeyBodyLength+apiKeyChecksumLength || len(tail) > MaxAllowedLength+apiKeyChecksumLength {
+		return prefix, fmt.Errorf("key body has the wrong length")
+	}
+	if !apiKeyTailPattern.MatchString(tail) {
+		return prefix, fmt.Errorf("key body must be base62")
+	}
+	body, checksum := tail[:len(tail)-apiKeyChecksumLength], tail[len(tail)-apiKeyChecksumLength:]
+	if checksum != apiKeyChecksum(prefix, body) {
+		return prefix, fmt.Errorf("checksum mismatch")
+	}
+	return prefix, nil
+}
+
+// apiKeyHandler handles GET /apikey?prefix=acme_live_&len=30&count=1
+func apiKeyHandler(c *gin.Context) {
+	length, ok, err := queryInt(c, "len", MinAPIKeyBodyLength, MaxAllowedLength)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !ok {
+		length = DefaultAPIKeyBodyLength
+	}
+	count, ok, err := queryInt(c, "count", 1, MaxBatchCount)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !ok {
+		count = 1
+	}
+	prefix := c.DefaultQuery("prefix", DefaultAPIKeyPrefix)
+	if err := validateAPIKeyPrefix(prefix); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	entropy := math.Round(float64(length)*math.Log2(float64(len(DefaultAlphanumericCharset)))*100) / 100
+	keys := make([]RandomString, count)
+	lines := make([]string, count)
+	for i := range keys {
+		key, err := NewAPIKey(prefix, length)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		keys[i] = RandomString{Length: len(key), String: key, Entropy: entropy}
+		lines[i] = key
+	}
+	respondList(c, lines, APIKeyResponse{Prefix: prefix, Keys: keys})
+}
+
+// readAPIKey returns the key from key= or, for POST, from a text or {"key": "..."} body,
+// so keys need not appear in URLs and access logs.
+func readAPIKey(c *gin.Context) (string, error) {
+	if key, ok := c.GetQuery("key"); ok {
+		return strings.TrimSpace(key), nil
+	}
+	if c.Request.Method != http.MethodPost {
+		return "", fmt.Errorf("key must not be empty")
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxAPIKeyBodyBytes))
+	if err != nil {
+		return "", fmt.Errorf("request body must be at most %d bytes", maxAPIKeyBodyBytes)
+	}
+	body = bytes.TrimSpace(body)
+	if !bytes.HasPrefix(body, []byte("{")) {
+		return string(body), nil
+	}
+	var req struct {
+		Key string `json:"key"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return "", fmt.Errorf("invalid JSON object: %v", err)
+	}
+	return strings.TrimSpace(req.Key), nil
+}
+
+// apiKeyVerifyHandler handles GET or POST /apikey/verify?key=...&prefix=...
+func apiKeyVerifyHandler(c *gin.Context) {
+	key, err := readAPIKey(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if key == "" {
+		respondError(c, fmt.Errorf("key must not be empty"))
+		return
+	}
+	prefix, err := VerifyAPIKey(key, c.Query("prefix"))
+	resp := APIKeyVerifyResponse{Valid: err == nil, Prefix: prefix}
+	line := "valid"
+	if err != nil {
+		resp.Reason = err.Error()
+		line = "invalid: " + resp.Reason
+	}
+	respondList(c, []string{line}, resp)
+}
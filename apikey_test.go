@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyRoundTrip(t *testing.T) {
+	key, err := NewAPIKey("acme_live_", DefaultAPIKeyBodyLength)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, "acme_live_"))
+	assert.Len(t, key, len("acme_live_")+DefaultAPIKeyBodyLength+apiKeyChecksumLength)
+
+	prefix, err := VerifyAPIKey(key, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "acme_live_", prefix)
+	_, err = VerifyAPIKey(key, "acme_live_")
+	assert.NoError(t, err)
+
+	_, err = VerifyAPIKey(key, "acme_test_")
+	assert.Error(t, err, "Expected prefix should be enforced")
+	_, err = VerifyAPIKey("acme_test_"+strings.TrimPrefix(key, "acme_live_"), "")
+	assert.Error(t, err, "Checksum should cover the prefix")
+
+	// Change one body character: CRC32 detects every single-character error
+	body := []byte(key)
+	i := len("acme_live_") + 5
+	if body[i] == 'a' {
+		body[i] = 'b'
+	} else {
+		body[i] = 'a'
+	}
+	_, err = VerifyAPIKey(string(body), "")
+	assert.Error(t, err)
+
+	for _, bad := range []string{"nounderscore", "acme_live_short", "acme_live_" + strings.Repeat("!", 36), "9bad_" + key[10:]} {
+		_, err = VerifyAPIKey(bad, "")
+		assert.Error(t, err, "Key %q should be rejected", bad)
+	}
+}
+
+func TestAPIKeyEndpoint(t *testing.T) {
+	r := setupRouter()
+
+	tests := []struct {
+		target  string
+		prefix  string
+		count   int
+		length  int
+		entropy float64
+	}{
+		{"/apikey", DefaultAPIKeyPrefix, 1, DefaultAPIKeyBodyLength, 178.63},
+		{"/apikey?prefix=ci_&len=40&count=3", "ci_", 3, 40, 238.17},
+		{"/apikey?prefix=acme_live_&len=16", "acme_live_", 1, 16, 95.27},
+	}
+	for _, tt := range tests {
+		w := serveRequest(r, http.MethodGet, tt.target, nil)
+		if !assert.Equal(t, http.StatusOK, w.Code, "Request %q", tt.target) {
+			continue
+		}
+		var resp APIKeyResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, tt.prefix, resp.Prefix)
+		assert.Len(t, resp.Keys, tt.count)
+		for _, key := range resp.Keys {
+			assert.Len(t, key.String, len(tt.prefix)+tt.length+apiKeyChecksumLength)
+			assert.Equal(t, tt.entropy, key.Entropy)
+			_, err := VerifyAPIKey(key.String, tt.prefix)
+			assert.NoError(t, err, "Generated key %q should verify", key.String)
+		}
+	}
+
+	for _, query := range []string{
+		"prefix=acme", "prefix=_x_", "prefix=9x_", "prefix=ac-me_", "prefix=" + strings.Repeat("a", maxAPIKeyPrefixLength) + "_",
+		"len=8", "len=101", "len=abc", "count=0", "count=101",
+	} {
+		w := serveRequest(r, http.MethodGet, "/apikey?"+query, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Query %q should be rejected", query)
+	}
+}
+
+func TestAPIKeyVerifyEndpoint(t *testing.T) {
+	r := setupRouter()
+	key, err := NewAPIKey("ci_", DefaultAPIKeyBodyLength)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		method string
+		target string
+		body   string
+		valid  bool
+	}{
+		{http.MethodGet, "/apikey/verify?key=" + key, "", true},
+		{http.MethodGet, "/apikey/verify?prefix=ci_&key=" + key, "", true},
+		{http.MethodPost, "/apikey/verify?prefix=ci_", `{"key": "` + key + `"}`, true},
+		{http.MethodPost, "/apikey/verify", key + "\n", true},
+		{http.MethodGet, "/apikey/verify?prefix=cd_&key=" + key, "", false},
+		{http.MethodGet, "/apikey/verify?key=" + key + "x", "", false},
+		{http.MethodPost, "/apikey/verify", `{"key": "nounderscore"}`, false},
+	}
+	for _, tt := range tests {
+		w := serveRequest(r, tt.method, tt.target, strings.NewReader(tt.body))
+		if !assert.Equal(t, http.StatusOK, w.Code, "%s %q", tt.method, tt.target) {
+			continue
+		}
+		var resp APIKeyVerifyResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, tt.valid, resp.Valid, "%s %q %s", tt.method, tt.target, tt.body)
+		assert.Equal(t, tt.valid, resp.Reason == "", "Only invalid keys should carry a reason")
+	}
+
+	w := serveRequest(r, http.MethodGet, "/apikey/verify?format=text&key="+key+"x", nil)
+	assert.True(t, strings.HasPrefix(w.Body.String(), "invalid: "), "Tampered key should be reported invalid")
+
+	for _, tt := range []struct{ method, target, body string }{
+		{http.MethodGet, "/apikey/verify", ""},
+		{http.MethodPost, "/apikey/verify", `{"key": ""}`},
+		{http.MethodPost, "/apikey/verify", `{"key": `},
+	} {
+		w := serveRequest(r, tt.method, tt.target, strings.NewReader(tt.body))
+		assert.Equal(t, http.StatusBadRequest, w.Code, "%s %q %s should be rejected", tt.method, tt.target, tt.body)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultByteCount = 32
+	MaxByteCount     = 4096
+)
+
+// BytesResponse is the JSON payload returned by /bytes
+type BytesResponse struct {
+	Bytes    int    `json:"bytes"`
+	Encoding string `json:"encoding"`
+	Value    string `json:"value"`
+}
+
+// byteEncoders maps the enc query parameter to its text encoding
+var byteEncoders = map[string]func([]byte) string{
+	"hex":       hex.EncodeToString,
+	"base64":    base64.StdEncoding.EncodeToString,
+	"base64url": base64.RawURLEncoding.EncodeToString,
+	"base32":    base32.StdEncoding.EncodeToString,
+}
+
+// generateBytes handles GET /bytes?n=32&enc=hex|base64|base64url|base32|raw
+func generateBytes(c *gin.Context) {
+	n, ok, err := queryInt(c, "n", 1, MaxByteCount)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !ok {
+		n = DefaultByteCount
+	}
+
+	enc := strings.ToLower(c.DefaultQuery("enc", "hex"))
+	b := cryptoRandBytes(n)
+	if enc == "raw" {
+		c.Header("Cache-Control", "no-store, no-cache, must-revalidate")
+		c.Data(http.StatusOK, "application/octet-stream", b)
+		return
+	}
+	encode, ok := byteEncoders[enc]
+	if !ok {
+		respondError(c, fmt.Errorf("enc must be one of hex, base64, base64url, base32, raw, got %q", enc))
+		return
+	}
+	value := encode(b)
+	respondList(c, []string{value}, BytesResponse{Bytes: n, Encoding: enc, Value: value})
+}
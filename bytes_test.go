@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBytesEndpoint(t *testing.T) {
+	r := setupRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/bytes?n=48&enc=base64url", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp BytesResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 48, resp.Bytes)
+	decoded, err := base64.RawURLEncoding.DecodeString(resp.Value)
+	assert.NoError(t, err)
+	assert.Len(t, decoded, 48, "Decoded value should contain exactly n bytes")
+
+	req = httptest.NewRequest(http.MethodGet, "/bytes?n=1000&enc=raw", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/octet-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, 1000, w.Body.Len())
+
+	for _, query := range []string{"n=0", "n=5000", "enc=base85"} {
+		req = httptest.NewRequest(http.MethodGet, "/bytes?"+query, nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Query %q should be rejected", query)
+	}
+}
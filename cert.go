@@ -0,0 +1,232 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultCertDays   = 90
+	MaxCertDays       = 825
+	DefaultCADays     = 3650
+	MaxCADays         = 3650
+	DefaultCertSANs   = "localhost,127.0.0.1,::1"
+	maxCertSANs       = 20
+	maxCertNameLength = 253
+	// certBackdate covers clock skew between the service and the client
+	certBackdate = time.Hour
+)
+
+// certDNSNamePattern accepts host names with an optional leading wildcard label
+var certDNSNamePattern = regexp.MustCompile(`^(\*\.)?([A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?\.)*[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
+
+// CertResponse is the JSON payload returned by /cert
+type CertResponse struct {
+	Certificate   string    `json:"certificate"`
+	PrivateKey    string    `json:"private_key"`
+	Chain         string    `json:"chain,omitempty"`
+	CACertificate string    `json:"ca_certificate,omitempty"`
+	CAPrivateKey  string    `json:"ca_private_key,omitempty"`
+	Subject       string    `json:"subject"`
+	SANs          []string  `json:"sans"`
+	KeyType       string    `json:"key_type"`
+	NotBefore     time.Time `json:"not_before"`
+	NotAfter      time.Time `json:"not_after"`
+	Fingerprint   string    `json:"fingerprint_sha256"`
+}
+
+// CertRequest describes the certificate(s) to issue
+type CertRequest struct {
+	CommonName string
+	SANs       []string
+	Days       int
+	CADays     int
+	WithCA     bool
+	Key        KeySpec
+}
+
+// certSerial returns a random positive 128-bit serial number
+func certSerial() *big.Int {
+	return new(big.Int).Add(cryptoRandBig(new(big.Int).Lsh(big.NewInt(1), 128)), big.NewInt(1))
+}
+
+// splitSANs sorts subject alternative names into IP addresses, email addresses and DNS names
+func splitSANs(template *x509.Certificate, sans []string) error {
+	for _, san := range sans {
+		switch {
+		case net.ParseIP(san) != nil:
+			template.IPAddresses = append(template.IPAddresses, net.ParseIP(san))
+		case strings.Contains(san, "@"):
+			template.EmailAddresses = append(template.EmailAddresses, san)
+		case len(san) <= maxCertNameLength && certDNSNamePattern.MatchString(san):
+			template.DNSNames = append(template.DNSNames, strings.ToLower(san))
+		default:
+			return fmt.Errorf("invalid SAN %q: expected a DNS name, IP address or email address", san)
+		}
+	}
+	return nil
+}
+
+// checkCost applies the per-request RSA limit; the CA key uses the same spec, so
+// ca=1 doubles the key generation work
+func (req CertRequest) checkCost() error {
+	if !req.WithCA {
+		return req.Key.checkCost(1)
+	}
+	if err := req.Key.checkCost(2); err != nil {
+		return fmt.Errorf("ca=1 also generates a CA key: %v", err)
+	}
+	return nil
+}
+
+// IssueCertificate creates a self-signed leaf certificate, or a local CA and a leaf signed by it
+func IssueCertificate(req CertRequest) (CertResponse, error) {
+	if err := req.checkCost(); err != nil {
+		return CertResponse{}, err
+	}
+	notBefore := time.Now().UTC().Truncate(time.Second).Add(-certBackdate)
+	leafKey, err := req.Key.Generate()
+	if err != nil {
+		return CertResponse{}, err
+	}
+	leaf := &x509.Certificate{
+		SerialNumber:          certSerial(),
+		Subject:               pkix.Name{CommonName: req.CommonName},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(time.Duration(req.Days) * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+	if _, ok := leafKey.(*rsa.PrivateKey); ok {
+		leaf.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+	if err := splitSANs(leaf, req.SANs); err != nil {
+		return CertResponse{}, err
+	}
+
+	resp := CertResponse{Subject: leaf.Subject.String(), SANs: req.SANs, KeyType: req.Key.Type}
+	parent, signer := leaf, crypto.Signer(leafKey)
+	if req.WithCA {
+		caKey, err := req.Key.Generate()
+		if err != nil {
+			return CertResponse{}, err
+		}
+		ca := &x509.Certificate{
+			SerialNumber:          certSerial(),
+			Subject:               pkix.Name{CommonName: "random local CA " + hex.EncodeToString(cryptoRandBytes(4))},
+			NotBefore:             notBefore,
+			NotAfter:              notBefore.Add(time.Duration(req.CADays) * 24 * time.Hour),
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+			MaxPathLenZero:        true,
+		}
+		if leaf.NotAfter.After(ca.NotAfter) {
+			return CertResponse{}, fmt.Errorf("days must not exceed ca_days")
+		}
+		caDER, err := x509.CreateCertificate(rand.Reader, ca, ca, caKey.Public(), caKey)
+		if err != nil {
+			return CertResponse{}, fmt.Errorf("cannot create CA certificate: %v", err)
+		}
+		if parent, err = x509.ParseCertificate(caDER); err != nil {
+			return CertResponse{}, err
+		}
+		signer = caKey
+		resp.CACertificate = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}))
+		if resp.CAPrivateKey, _, err = EncodeKeypairPEM(caKey); err != nil {
+			return CertResponse{}, err
+		}
+	}
+
+	leafDER, err := x509.CreateCertificate(rand.Reader, leaf, parent, leafKey.Public(), signer)
+	if err != nil {
+		return CertResponse{}, fmt.Errorf("cannot create certificate: %v", err)
+	}
+	if resp.PrivateKey, _, err = EncodeKeypairPEM(leafKey); err != nil {
+		return CertResponse{}, err
+	}
+	sum := sha256.Sum256(leafDER)
+	resp.Certificate = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER}))
+	resp.NotBefore, resp.NotAfter = leaf.NotBefore, leaf.NotAfter
+	resp.Fingerprint = hex.EncodeToString(sum[:])
+	if req.WithCA {
+		resp.Chain = resp.Certificate + resp.CACertificate
+	}
+	return resp, nil
+}
+
+// parseCertRequest reads the /cert query parameters
+func parseCertRequest(c *gin.Context) (CertRequest, error) {
+	var req CertRequest
+	var err error
+	if req.Key, err = parseKeySpec(c, "ec"); err != nil {
+		return req, err
+	}
+	if req.WithCA, err = queryBool(c, "ca"); err != nil {
+		return req, err
+	}
+	days, ok, err := queryInt(c, "days", 1, MaxCertDays)
+	if err != nil {
+		return req, err
+	}
+	if !ok {
+		days = DefaultCertDays
+	}
+	caDays, ok, err := queryInt(c, "ca_days", 1, MaxCADays)
+	if err != nil {
+		return req, err
+	}
+	if !ok {
+		caDays = DefaultCADays
+	}
+	req.Days, req.CADays = days, caDays
+
+	for _, san := range strings.Split(c.DefaultQuery("san", DefaultCertSANs), ",") {
+		if san = strings.TrimSpace(san); san != "" {
+			req.SANs = append(req.SANs, san)
+		}
+	}
+	if len(req.SANs) == 0 || len(req.SANs) > maxCertSANs {
+		return req, fmt.Errorf("san must list between 1 and %d names", maxCertSANs)
+	}
+	req.CommonName = c.DefaultQuery("cn", req.SANs[0])
+	if len(req.CommonName) > 64 {
+		return req, fmt.Errorf("cn must be at most 64 bytes")
+	}
+	return req, nil
+}
+
+// certHandler handles GET /cert?san=localhost,127.0.0.1&days=90&ca=1&type=ec&curve=P-256
+func certHandler(c *gin.Context) {
+	req, err := parseCertRequest(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	resp, err := IssueCertificate(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	lines := []string{strings.TrimSuffix(resp.Certificate, "\n")}
+	if req.WithCA {
+		lines = append(lines, strings.TrimSuffix(resp.CACertificate, "\n"))
+	}
+	lines = append(lines, strings.TrimSuffix(resp.PrivateKey, "\n"))
+	respondList(c, lines, resp)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func parseCertPEM(t *testing.T, s string) *x509.Certificate {
+	block, _ := pem.Decode([]byte(s))
+	if !assert.NotNil(t, block) {
+		t.FailNow()
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return cert
+}
+
+func TestCertEndpoint(t *testing.T) {
+	r := setupRouter()
+
+	tests := []struct {
+		query   string
+		keyType string
+		ca      bool
+		verify  string // DNS name or IP the leaf must verify for
+		days    int
+	}{
+		{"", "ec", false, "localhost", DefaultCertDays},
+		{"san=dev.example.test,*.dev.example.test,10.0.0.5&days=30", "ec", false, "api.dev.example.test", 30},
+		{"san=dev.example.test,*.dev.example.test,10.0.0.5&days=30", "ec", false, "10.0.0.5", 30},
+		{"type=ed25519&ca=1&san=app.localhost", "ed25519", true, "app.localhost", DefaultCertDays},
+		{"type=rsa&bits=2048&ca=1&days=7&ca_days=7", "rsa", true, "127.0.0.1", 7},
+	}
+	for _, tt := range tests {
+		w := serveRequest(r, http.MethodGet, "/cert?"+tt.query, nil)
+		if !assert.Equal(t, http.StatusOK, w.Code, "Query %q", tt.query) {
+			continue
+		}
+		var resp CertResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, tt.keyType, resp.KeyType)
+
+		cert := parseCertPEM(t, resp.Certificate)
+		assert.Equal(t, time.Duration(tt.days)*24*time.Hour, cert.NotAfter.Sub(cert.NotBefore))
+		assert.False(t, cert.IsCA)
+		root := cert
+		if tt.ca {
+			root = parseCertPEM(t, resp.CACertificate)
+			assert.True(t, root.IsCA)
+			assert.Equal(t, resp.Certificate+resp.CACertificate, resp.Chain)
+		} else {
+			assert.Empty(t, resp.CACertificate)
+			assert.NoError(t, cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature),
+				"Certificate should be self-signed")
+		}
+		roots := x509.NewCertPool()
+		roots.AddCert(root)
+		_, err := cert.Verify(x509.VerifyOptions{DNSName: tt.verify, Roots: roots})
+		assert.NoError(t, err, "Certificate from %q should verify for %s", tt.query, tt.verify)
+
+		block, _ := pem.Decode([]byte(resp.PrivateKey))
+		if !assert.NotNil(t, block) {
+			continue
+		}
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		assert.NoError(t, err)
+		assert.True(t, key.(crypto.Signer).Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(cert.PublicKey),
+			"Private key should match the certificate")
+	}
+
+	for _, query := range []string{
+		"san=bad_name!", "san=,", "san=" + strings.Repeat("a,", maxCertSANs+1), "cn=" + strings.Repeat("c", 65),
+		"days=0", "days=900", "ca=1&days=400&ca_days=30", "ca_days=4000", "ca=maybe",
+		"type=dsa", "type=rsa&bits=1024", "type=rsa&bits=2500", "type=ec&curve=P-521", "type=rsa&bits=4096&ca=1",
+	} {
+		w := serveRequest(r, http.MethodGet, "/cert?"+query, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Query %q should be rejected", query)
+	}
+}
+
+func TestCASignedCert(t *testing.T) {
+	resp, err := IssueCertificate(CertRequest{
+		CommonName: "localhost",
+		SANs:       []string{"localhost", "127.0.0.1", "dev@example.test"},
+		Days:       90,
+		CADays:     365,
+		WithCA:     true,
+		Key:        KeySpec{Type: "rsa", Bits: 2048},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ca := parseCertPEM(t, resp.CACertificate)
+	leaf := parseCertPEM(t, resp.Certificate)
+	assert.True(t, ca.IsCA)
+	assert.False(t, leaf.IsCA)
+	assert.Equal(t, []string{"dev@example.test"}, leaf.EmailAddresses)
+	assert.Equal(t, resp.Certificate+resp.CACertificate, resp.Chain)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	_, err = leaf.Verify(x509.VerifyOptions{DNSName: "localhost", Roots: roots})
+	assert.NoError(t, err, "Leaf should chain to the local CA")
+	_, err = leaf.Verify(x509.VerifyOptions{DNSName: "127.0.0.1", Roots: roots})
+	assert.NoError(t, err)
+}
+
+func TestCertRSACost(t *testing.T) {
+	req := CertRequest{CommonName: "localhost", SANs: []string{"localhost"}, Days: 1, CADays: 1, WithCA: true,
+		Key: KeySpec{Type: "rsa", Bits: 4096}}
+	_, err := IssueCertificate(req)
+	assert.Error(t, err, "ca=1 with RSA-4096 needs two 4096-bit keys and should be rejected")
+	assert.NoError(t, req.Key.checkCost(1), "A single RSA-4096 leaf stays within the limit")
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxDiceTerms      = 20
+	maxDicePerTerm    = 100
+	maxDiceSides      = 1000
+	maxDiceConstant   = 1000000
+	maxDiceExplosions = 100
+)
+
+// diceTermPattern matches one dice term such as 4d6, d%, 3d10!, 4d6kh3 or 2d20kl1
+var diceTermPattern = regexp.MustCompile(`^(\d*)d(\d+|%)(!?)(?:(kh|kl|k|dh|dl)(\d+))?$`)
+
+// DiceTerm is one signed term of a dice expression and its outcome
+type DiceTerm struct {
+	Expr  string `json:"expr"`
+	Rolls []int  `json:"rolls,omitempty"`
+	Kept  []int  `json:"kept,omitempty"`
+	Value int    `json:"value"`
+}
+
+// RollResponse is the JSON payload returned by /roll
+type RollResponse struct {
+	Expr  string     `json:"expr"`
+	Terms []DiceTerm `json:"terms"`
+	Total int        `json:"total"`
+}
+
+// diceSpec is a parsed dice term
+type diceSpec struct {
+	count   int
+	sides   int
+	explode bool
+	keep    string // "", "kh", "kl", "dh" or "dl"
+	keepN   int
+}
+
+// RollDice parses and evaluates a dice expression such as "4d6kh3+2" or "2d20kl1-1d4+3".
+// Terms are joined by + or -; each term is a constant or NdS with an optional "!"
+// (exploding dice) and an optional kh/kl/k/dh/dl keep or drop modifier.
+func RollDice(expr string) (RollResponse, error) {
+	compact := strings.ToLower(strings.Join(strings.Fields(expr), ""))
+	if compact == "" {
+		return RollResponse{}, fmt.Errorf("expr must not be empty")
+	}
+
+	resp := RollResponse{Expr: compact}
+	for i := 0; i < len(compact); {
+		sign := 1
+		start := i
+		if compact[i] == '+' || compact[i] == '-' {
+			if compact[i] == '-' {
+				sign = -1
+			}
+			i++
+		}
+		end := i
+		for end < len(compact) && compact[end] != '+' && compact[end] != '-' {
+			end++
+		}
+		if end == i {
+			return RollResponse{}, fmt.Errorf("missing term at position %d of %q", i, compact)
+		}
+		if len(resp.Terms) == maxDiceTerms {
+			return RollResponse{}, fmt.Errorf("expr may contain at most %d terms", maxDiceTerms)
+		}
+		term, err := rollTerm(compact[i:end], sign)
+		if err != nil {
+			return RollResponse{}, err
+		}
+		term.Expr = compact[start:end]
+		resp.Terms = append(resp.Terms, term)
+		resp.Total += term.Value
+		i = end
+	}
+	return resp, nil
+}
+
+// rollTerm evaluates a single unsigned term and applies sign to its value
+func rollTerm(s string, sign int) (DiceTerm, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n > maxDiceConstant {
+			return DiceTerm{}, fmt.Errorf("constant %d exceeds %d", n, maxDiceConstant)
+		}
+		return DiceTerm{Value: sign * n}, nil
+	}
+
+	spec, err := parseDiceSpec(s)
+	if err != nil {
+		return DiceTerm{}, err
+	}
+	rolls := make([]int, spec.count)
+	for i := range rolls {
+		rolls[i] = rollDie(spec.sides, spec.explode)
+	}
+	kept := keepDice(rolls, spec.keep, spec.keepN)
+	value := 0
+	for _, v := range kept {
+		value += v
+	}
+	term := DiceTerm{Rolls: rolls, Value: sign * value}
+	if spec.keep != "" {
+		term.Kept = kept
+	}
+	return term, nil
+}
+
+// parseDiceSpec validates a dice term against the limits of the service
+func parseDiceSpec(s string) (diceSpec, error) {
+	m := diceTermPattern.FindStringSubmatch(s)
+	if m == nil {
+		return diceSpec{}, fmt.Errorf("invalid dice term %q", s)
+	}
+	spec := diceSpec{count: 1, explode: m[3] == "!", keep: m[4]}
+	if m[1] != "" {
+		spec.count, _ = strconv.Atoi(m[1])
+	}
+	if m[2] == "%" {
+		spec.sides = 100
+	} else {
+		spec.sides, _ = strconv.Atoi(m[2])
+	}
+	if spec.keep == "k" {
+		spec.keep = "kh"
+	}
+	if m[5] != "" {
+		spec.keepN, _ = strconv.Atoi(m[5])
+	}
+
+	switch {
+	case spec.count < 1 || spec.count > maxDicePerTerm:
+		return spec, fmt.Errorf("dice count in %q must be between 1 and %d", s, maxDicePerTerm)
+	case spec.sides < 1 || spec.sides > maxDiceSides:
+		return spec, fmt.Errorf("dice sides in %q must be between 1 and %d", s, maxDiceSides)
+	case spec.explode && spec.sides < 2:
+		return spec, fmt.Errorf("exploding dice in %q need at least 2 sides", s)
+	case spec.keep != "" && spec.keepN > spec.count:
+		return spec, fmt.Errorf("cannot keep or drop %d of %d dice in %q", spec.keepN, spec.count, s)
+	}
+	return spec, nil
+}
+
+// rollDie rolls one die with cryptoRandInt; exploding dice roll again and add while they show the maximum
+func rollDie(sides int, explode bool) int {
+	total := 0
+	for i := 0; i <= maxDiceExplosions; i++ {
+		roll := cryptoRandInt(sides) + 1
+		total += roll
+		if !explode || roll != sides {
+			break
+		}
+	}
+	return total
+}
+
+// keepDice returns the dice kept by the modifier, in their original roll order
+func keepDice(rolls []int, keep string, n int) []int {
+	if keep == "" {
+		return rolls
+	}
+	idx := make([]int, len(rolls))
+	for i := range idx {
+		idx[i] = i
+	}
+	// Sort ascending by value so the lowest dice come first
+	sort.SliceStable(idx, func(a, b int) bool { return rolls[idx[a]] < rolls[idx[b]] })
+
+	var selected []int
+	switch keep {
+	case "kh":
+		selected = idx[len(idx)-n:]
+	case "kl":
+		selected = idx[:n]
+	case "dh":
+		selected = idx[:len(idx)-n]
+	case "dl":
+		selected = idx[n:]
+	}
+	sort.Ints(selected)
+	kept := make([]int, len(selected))
+	for i, j := range selected {
+		kept[i] = rolls[j]
+	}
+	return kept
+}
+
+// rawQueryValue returns a query parameter without decoding "+" as a space,
+// so expressions like expr=4d6+2 survive unencoded.
+func rawQueryValue(c *gin.Context, key string) (string, bool) {
+	for _, pair := range strings.Split(c.Request.URL.RawQuery, "&") {
+		k, v, _ := strings.Cut(pair, "=")
+		if k != key {
+			continue
+		}
+		if decoded, err := url.PathUnescape(v); err == nil {
+			return decoded, true
+		}
+		return v, true
+	}
+	return "", false
+}
+
+// rollHandler handles GET /roll?expr=4d6kh3+2
+func rollHandler(c *gin.Context) {
+	expr, ok := rawQueryValue(c, "expr")
+	if !ok {
+		expr = "1d6"
+	}
+	resp, err := RollDice(expr)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	lines := make([]string, 0, len(resp.Terms)+1)
+	for _, term := range resp.Terms {
+		line := fmt.Sprintf("%s = %d", term.Expr, term.Value)
+		if term.Rolls != nil {
+			line += fmt.Sprintf(" rolls %v", term.Rolls)
+		}
+		if term.Kept != nil {
+			line += fmt.Sprintf(" kept %v", term.Kept)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, fmt.Sprintf("total = %d", resp.Total))
+	respondList(c, lines, resp)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollDice(t *testing.T) {
+	resp, err := RollDice("4d6kh3+2")
+	assert.NoError(t, err)
+	assert.Len(t, resp.Terms, 2)
+	assert.Len(t, resp.Terms[0].Rolls, 4)
+	assert.Len(t, resp.Terms[0].Kept, 3)
+	assert.Equal(t, 2, resp.Terms[1].Value)
+	assert.True(t, resp.Total >= 5 && resp.Total <= 20, "Total %d should be in [5, 20]", resp.Total)
+
+	resp, err = RollDice("2d20kl1 - 3")
+	assert.NoError(t, err)
+	assert.Equal(t, resp.Terms[0].Value-3, resp.Total)
+
+	resp, err = RollDice("10d2!")
+	assert.NoError(t, err)
+	for _, roll := range resp.Terms[0].Rolls {
+		assert.NotEqual(t, 2, roll, "Exploded dice never stop on the maximum")
+	}
+
+	for _, expr := range []string{"", "4d", "d0", "101d6", "3d6kh4", "1d1!", "2d6+"} {
+		_, err = RollDice(expr)
+		assert.Error(t, err, "Expression %q should be rejected", expr)
+	}
+}
+
+func TestKeepDice(t *testing.T) {
+	rolls := []int{3, 6, 1, 5}
+	assert.Equal(t, []int{6, 5}, keepDice(rolls, "kh", 2))
+	assert.Equal(t, []int{3, 1}, keepDice(rolls, "kl", 2))
+	assert.Equal(t, []int{3, 1, 5}, keepDice(rolls, "dh", 1))
+	assert.Equal(t, []int{3, 6, 5}, keepDice(rolls, "dl", 1))
+}
+
+func TestRollEndpoint(t *testing.T) {
+	r := setupRouter()
+
+	// An unencoded "+" must not be decoded as a space
+	req := httptest.NewRequest(http.MethodGet, "/roll?expr=1d4+100", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp RollResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "1d4+100", resp.Expr)
+	assert.True(t, resp.Total > 100 && resp.Total <= 104)
+
+	req = httptest.NewRequest(http.MethodGet, "/roll?expr=banana", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultDrawK = 6
+	DefaultDrawN = 49
+	MaxDrawN     = 1000000
+)
+
+// DrawTranscript records the parameters and result of a draw; it is the signed part of a DrawResponse
+type DrawTranscript struct {
+	ID           string  `json:"id"`
+	Timestamp    string  `json:"timestamp"`
+	K            int     `json:"k"`
+	N            int     `json:"n"`
+	Numbers      []int64 `json:"numbers"`
+	KeyID        string  `json:"key_id"`
+	PublicKey    string  `json:"public_key"`
+	EphemeralKey bool    `json:"ephemeral_key,omitempty"`
+}
+
+// DrawResponse is the JSON payload returned by /draw. Payload is the exact JSON
+// encoding of Transcript that was signed, so verifiers do not need to re-encode it.
+type DrawResponse struct {
+	Transcript DrawTranscript `json:"transcript"`
+	Payload    string         `json:"payload"`
+	Signature  string         `json:"signature"`
+}
+
+// DrawKeyResponse is the JSON payload returned by /draw/key
+type DrawKeyResponse struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"key_id"`
+	PublicKey string `json:"public_key"`
+	Ephemeral bool   `json:"ephemeral"`
+}
+
+// drawKey is the ed25519 key used to sign draw transcripts. It is loaded from
+// DRAW_SIGNING_KEY (base64 32-byte seed) or generated per process when unset.
+var drawKey struct {
+	once      sync.Once
+	priv      ed25519.PrivateKey
+	id        string
+	ephemeral bool
+	err       error
+}
+
+// parseDrawSigningKey decodes a base64 ed25519 seed as used in DRAW_SIGNING_KEY
+func parseDrawSigningKey(encoded string) (ed25519.PrivateKey, error) {
+	seed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("DRAW_SIGNING_KEY must be a base64 encoded %d-byte ed25519 seed", ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// drawSigningKey returns the transcript signing key and its short identifier.
+// main calls it at startup so a malformed DRAW_SIGNING_KEY fails before serving.
+func drawSigningKey() (ed25519.PrivateKey, string, error) {
+	drawKey.once.Do(func() {
+		if encoded := os.Getenv("DRAW_SIGNING_KEY"); encoded != "" {
+			drawKey.priv, drawKey.err = parseDrawSigningKey(encoded)
+			if drawKey.err != nil {
+				return
+			}
+		} else {
+			log.Printf("DRAW_SIGNING_KEY not set, signing draw transcripts with an ephemeral key")
+			drawKey.priv = ed25519.NewKeyFromSeed(cryptoRandBytes(ed25519.SeedSize))
+			drawKey.ephemeral = true
+		}
+		drawKey.id = drawKeyID(drawKey.priv.Public().(ed25519.PublicKey))
+	})
+	return drawKey.priv, drawKey.id, drawKey.err
+}
+
+// drawKeyID returns the first 8 bytes of the SHA-256 of pub, hex encoded
+func drawKeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Draw picks k unique numbers from 1..n in draw order and returns the signed transcript
+func Draw(k, n int) (DrawResponse, error) {
+	if k > n {
+		return DrawResponse{}, fmt.Errorf("cannot draw %d unique numbers from 1..%d", k, n)
+	}
+	numbers, err := RandomInts(1, int64(n), k, true)
+	if err != nil {
+		return DrawResponse{}, err
+	}
+
+	priv, keyID, err := drawSigningKey()
+	if err != nil {
+		return DrawResponse{}, err
+	}
+	transcript := DrawTranscript{
+		ID:           FormatUUID(NewUUIDv7()),
+		Timestamp:    time.Now().UTC().Format(time.RFC3339Nano),
+		K:            k,
+		N:            n,
+		Numbers:      numbers,
+		KeyID:        keyID,
+		PublicKey:    base64.StdEncoding.EncodeToString(priv.Public().(ed25519.PublicKey)),
+		EphemeralKey: drawKey.ephemeral,
+	}
+	payload, err := json.Marshal(transcript)
+	if err != nil {
+		return DrawResponse{}, err
+	}
+	return DrawResponse{
+		Transcript: transcript,
+		Payload:    base64.StdEncoding.EncodeToString(payload),
+		Signature:  base64.StdEncoding.EncodeToString(ed25519.Sign(priv, payload)),
+	}, nil
+}
+
+// VerifyDraw checks the signature of a draw response against the trusted public key
+// pub (for example the one published at /draw/key), that the signed transcript names
+// that key, and that the transcript matches the signed payload. The key embedded in the
+// transcript is never trusted on its own: anyone can sign a transcript with their own key.
+func VerifyDraw(resp DrawResponse, pub ed25519.PublicKey) error {
+	if len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("trusted public key is invalid")
+	}
+	payload, err := base64.StdEncoding.DecodeString(resp.Payload)
+	if err != nil {
+		return fmt.Errorf("payload is not valid base64")
+	}
+	sig, err := base64.StdEncoding.DecodeString(resp.Signature)
+	if err != nil {
+		return fmt.Errorf("signature is not valid base64")
+	}
+	if !ed25519.Verify(pub, payload, sig) {
+		return fmt.Errorf("signature was not made with the trusted key")
+	}
+	var signed DrawTranscript
+	if err := json.Unmarshal(payload, &signed); err != nil {
+		return fmt.Errorf("payload is not a transcript")
+	}
+	if signed.PublicKey != base64.StdEncoding.EncodeToString(pub) || signed.KeyID != drawKeyID(pub) {
+		return fmt.Errorf("transcript names a different signing key")
+	}
+	expected, err := json.Marshal(resp.Transcript)
+	if err != nil || string(expected) != string(payload) {
+		return fmt.Errorf("transcript does not match the signed payload")
+	}
+	return nil
+}
+
+// respondDrawKeyError reports an unusable signing key as a server-side failure
+func respondDrawKeyError(c *gin.Context, err error) {
+	c.Header("Cache-Control", "no-store, no-cache, must-revalidate")
+	c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// drawKeyHandler handles GET /draw/key, publishing the key auditors verify transcripts against.
+// The response is never cached: an ephemeral key differs per process (every Lambda
+// instance has its own) and a configured key may be rotated, so a cached copy could
+// make valid transcripts fail verification.
+func drawKeyHandler(c *gin.Context) {
+	priv, keyID, err := drawSigningKey()
+	if err != nil {
+		respondDrawKeyError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store, no-cache, must-revalidate")
+	c.IndentedJSON(http.StatusOK, DrawKeyResponse{
+		Algorithm: "ed25519",
+		KeyID:     keyID,
+		PublicKey: base64.StdEncoding.EncodeToString(priv.Public().(ed25519.PublicKey)),
+		Ephemeral: drawKey.ephemeral,
+	})
+}
+
+// drawHandler handles GET /draw?k=6&n=49
+func drawHandler(c *gin.Context) {
+	k, ok, err := queryInt(c, "k", 1, MaxIntCount)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !ok {
+		k = DefaultDrawK
+	}
+	n, ok, err := queryInt(c, "n", 1, MaxDrawN)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !ok {
+		n = DefaultDrawN
+	}
+
+	if _, _, err := drawSigningKey(); err != nil {
+		respondDrawKeyError(c, err)
+		return
+	}
+	resp, err := Draw(k, n)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store, no-cache, must-revalidate")
+	c.IndentedJSON(http.StatusOK, resp)
+}
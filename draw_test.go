@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// serviceDrawKey returns the public key the service signs transcripts with
+func serviceDrawKey(t *testing.T) ed25519.PublicKey {
+	priv, _, err := drawSigningKey()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return priv.Public().(ed25519.PublicKey)
+}
+
+func TestDraw(t *testing.T) {
+	pub := serviceDrawKey(t)
+	resp, err := Draw(6, 49)
+	assert.NoError(t, err)
+	assert.Len(t, resp.Transcript.Numbers, 6)
+	seen := map[int64]bool{}
+	for _, v := range resp.Transcript.Numbers {
+		assert.True(t, v >= 1 && v <= 49, "Number %d should be in 1..49", v)
+		assert.False(t, seen[v], "Number %d should not repeat", v)
+		seen[v] = true
+	}
+	assert.NoError(t, VerifyDraw(resp, pub), "Fresh transcript should verify")
+
+	resp.Transcript.Numbers[0]++
+	assert.Error(t, VerifyDraw(resp, pub), "Tampered transcript should not verify")
+
+	_, err = Draw(10, 5)
+	assert.Error(t, err)
+}
+
+func TestVerifyDrawRejectsForgedKey(t *testing.T) {
+	pub := serviceDrawKey(t)
+
+	// A forger signs a transcript of their choosing with their own key and embeds it
+	forger := ed25519.NewKeyFromSeed(cryptoRandBytes(ed25519.SeedSize))
+	forgerPub := forger.Public().(ed25519.PublicKey)
+	transcript := DrawTranscript{ID: "forged", K: 1, N: 49, Numbers: []int64{7},
+		KeyID: drawKeyID(forgerPub), PublicKey: base64.StdEncoding.EncodeToString(forgerPub)}
+	payload, err := json.Marshal(transcript)
+	assert.NoError(t, err)
+	forged := DrawResponse{
+		Transcript: transcript,
+		Payload:    base64.StdEncoding.EncodeToString(payload),
+		Signature:  base64.StdEncoding.EncodeToString(ed25519.Sign(forger, payload)),
+	}
+	assert.Error(t, VerifyDraw(forged, pub), "Self-signed transcript must not verify against the service key")
+}
+
+func TestParseDrawSigningKey(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	key, err := parseDrawSigningKey(base64.StdEncoding.EncodeToString(seed))
+	assert.NoError(t, err)
+	assert.Equal(t, ed25519.NewKeyFromSeed(seed), key)
+
+	for _, bad := range []string{"not base64!", base64.StdEncoding.EncodeToString(seed[:16])} {
+		_, err = parseDrawSigningKey(bad)
+		assert.Error(t, err, "Seed %q should be rejected", bad)
+	}
+}
+
+func TestDrawEndpoint(t *testing.T) {
+	r := setupRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/draw/key", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store, no-cache, must-revalidate", w.Header().Get("Cache-Control"))
+	var key DrawKeyResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &key))
+	assert.Equal(t, "ed25519", key.Algorithm)
+	assert.True(t, key.Ephemeral, "Tests run without DRAW_SIGNING_KEY")
+	pub, err := base64.StdEncoding.DecodeString(key.PublicKey)
+	assert.NoError(t, err)
+
+	req = httptest.NewRequest(http.MethodGet, "/draw?k=5&n=5", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp DrawResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.ElementsMatch(t, []int64{1, 2, 3, 4, 5}, resp.Transcript.Numbers)
+	assert.Equal(t, key.KeyID, resp.Transcript.KeyID)
+	assert.True(t, resp.Transcript.EphemeralKey, "Ephemeral keys should be marked in the signed transcript")
+	assert.NoError(t, VerifyDraw(resp, pub), "Transcript should verify against the published key")
+
+	req = httptest.NewRequest(http.MethodGet, "/draw?k=7&n=6", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
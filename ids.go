@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// CrockfordAlphabet is Crockford's base32 alphabet used by ULIDs
+	CrockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	// base62Alphabet is the digit ordering used by KSUIDs
+	base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	// NanoIDAlphabet is the default URL-friendly NanoID alphabet
+	NanoIDAlphabet = "useandom-26T198340PX75pxJACKVERYMINDBUSHWOLF_GQZbfghjklqvwyzrict"
+
+	DefaultNanoIDSize = 21
+	// ksuidEpoch is the KSUID epoch (2014-05-13T16:53:20Z) in Unix seconds
+	ksuidEpoch = 1400000000
+)
+
+// IDResponse is the JSON payload returned by /id
+type IDResponse struct {
+	Type string         `json:"type"`
+	IDs  []RandomString `json:"ids"`
+}
+
+// ulidState remembers the last ULID so monotonic ULIDs increase within a millisecond
+var ulidState struct {
+	sync.Mutex
+	ms     uint64
+	random [10]byte
+}
+
+// NewULID returns a ULID: a 48-bit millisecond timestamp followed by 80 random bits,
+// encoded as 26 Crockford base32 characters. When monotonic is set and the clock has
+// not advanced since the previous monotonic ULID, the random part of that ULID is
+// incremented instead of drawn afresh; on overflow the timestamp moves forward.
+func NewULID(monotonic bool) string {
+	now := uint64(time.Now().UnixMilli())
+	var id [16]byte
+	var random [10]byte
+	copy(random[:], cryptoRandBytes(10))
+
+	if monotonic {
+		ulidState.Lock()
+		if now <= ulidState.ms {
+			now, random = ulidState.ms, ulidState.random
+			if incrementBytes(random[:]) {
+				now++
+			}
+		}
+		ulidState.ms, ulidState.random = now, random
+		ulidState.Unlock()
+	}
+
+	binary.BigEndian.PutUint64(id[0:8], now<<16)
+	copy(id[6:], random[:])
+	return encodeBase(id[:], CrockfordAlphabet, 26)
+}
+
+// NewKSUID returns a KSUID: a 32-bit timestamp (seconds since the KSUID epoch)
+// followed by 128 random bits, encoded as 27 base62 characters.
+func NewKSUID() string {
+	var id [20]byte
+	binary.BigEndian.PutUint32(id[0:4], uint32(time.Now().Unix()-ksuidEpoch))
+	copy(id[4:], cryptoRandBytes(16))
+	return encodeBase(id[:], base62Alphabet, 27)
+}
+
+// NewNanoID returns a NanoID of the given size drawn uniformly from alphabet
+func NewNanoID(size int, alphabet []rune) string {
+	return GenerateRandomFromCharset(size, alphabet)
+}
+
+// incrementBytes adds one to b as a big-endian integer and reports whether it overflowed
+func incrementBytes(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeBase encodes b as a big-endian number in the given alphabet, left padded to width
+func encodeBase(b []byte, alphabet string, width int) string {
+	n := new(big.Int).SetBytes(b)
+	base := big.NewInt(int64(len(alphabet)))
+	mod := new(big.Int)
+	out := make([]byte, 0, width)
+	for n.Sign() > 0 {
+		n.DivMod(n, base, mod)
+		out = append(out, alphabet[mod.Int64()])
+	}
+	for len(out) < width {
+		out = append(out, alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+// newIDFunc parses the type specific options of /id and returns a generator for one ID
+func newIDFunc(c *gin.Context, kind string) (func() RandomString, error) {
+	switch kind {
+	case "ulid":
+		monotonic, err := queryBool(c, "monotonic")
+		if err != nil {
+			return nil, err
+		}
+		return func() RandomString {
+			return RandomString{Length: 26, String: NewULID(monotonic), Entropy: 80}
+		}, nil
+	case "ksuid":
+		return func() RandomString {
+			return RandomString{Length: 27, String: NewKSUID(), Entropy: 128}
+		}, nil
+	case "nanoid":
+		size, ok, err := queryInt(c, "size", 2, MaxAllowedLength)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			size = DefaultNanoIDSize
+		}
+		alphabet := []rune(NanoIDAlphabet)
+		custom := ""
+		if val, ok := c.GetQuery("alphabet"); ok {
+			if alphabet, err = ParseCharset(val); err != nil {
+				return nil, err
+			}
+			custom = string(alphabet)
+		}
+		entropy := math.Round(math.Log2(float64(len(alphabet)))*float64(size)*100) / 100
+		return func() RandomString {
+			return RandomString{Length: size, String: NewNanoID(size, alphabet), Charset: custom, Entropy: entropy}
+		}, nil
+	default:
+		return nil, fmt.Errorf("type must be one of ulid, ksuid, nanoid, got %q", kind)
+	}
+}
+
+// generateIDs handles GET /id?type=ulid|ksuid|nanoid&count=N
+func generateIDs(c *gin.Context) {
+	kind := strings.ToLower(c.DefaultQuery("type", "ulid"))
+	next, err := newIDFunc(c, kind)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	count, ok, err := queryInt(c, "count", 1, MaxBatchCount)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !ok {
+		count = 1
+	}
+
+	ids := make([]RandomString, count)
+	lines := make([]string, count)
+	for i := range ids {
+		ids[i] = next()
+		lines[i] = ids[i].String
+	}
+	respondList(c, lines, IDResponse{Type: kind, IDs: ids})
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewULIDMonotonic(t *testing.T) {
+	prev := NewULID(true)
+	assert.Len(t, prev, 26)
+	for i := 0; i < 5000; i++ {
+		next := NewULID(true)
+		for _, r := range next {
+			assert.True(t, strings.ContainsRune(CrockfordAlphabet, r), "ULID %s should be Crockford base32", next)
+		}
+		if !assert.Less(t, prev, next, "Monotonic ULIDs should be strictly increasing") {
+			return
+		}
+		prev = next
+	}
+}
+
+func TestNewKSUID(t *testing.T) {
+	id := NewKSUID()
+	assert.Len(t, id, 27)
+	assert.Equal(t, "000000000000000000000000000", encodeBase(make([]byte, 20), base62Alphabet, 27))
+	assert.Equal(t, "aWgEPTl1tmebfsQzFP4bxwgy80V", encodeBase([]byte{
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	}, base62Alphabet, 27), "Maximum KSUID should match the reference encoding")
+}
+
+func TestIDEndpoint(t *testing.T) {
+	r := setupRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/id?type=nanoid&size=10&alphabet=abc&count=4", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp IDResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "nanoid", resp.Type)
+	assert.Len(t, resp.IDs, 4)
+	for _, id := range resp.IDs {
+		assert.Equal(t, 10, id.Length)
+		assert.Equal(t, "", strings.Trim(id.String, "abc"), "NanoID should only use the custom alphabet")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/id?type=snowflake", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
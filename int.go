@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultIntMin = 1
+	DefaultIntMax = 100
+	MaxIntCount   = 1000
+)
+
+// IntResponse is the JSON payload returned by /int
+type IntResponse struct {
+	Min    int64   `json:"min"`
+	Max    int64   `json:"max"`
+	Count  int     `json:"count"`
+	Unique bool    `json:"unique"`
+	Values []int64 `json:"values"`
+}
+
+// queryInt64 parses an optional 64-bit integer query parameter
+func queryInt64(c *gin.Context, key string, def int64) (int64, error) {
+	val, ok := c.GetQuery(key)
+	if !ok {
+		return def, nil
+	}
+	n, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a 64-bit integer, got %q", key, val)
+	}
+	return n, nil
+}
+
+// RandomInts returns count integers drawn uniformly from [min, max]. With unique set
+// the values are distinct; duplicates are redrawn, which keeps the sample uniform.
+func RandomInts(min, max int64, count int, unique bool) ([]int64, error) {
+	if min > max {
+		return nil, fmt.Errorf("min (%d) must not exceed max (%d)", min, max)
+	}
+	if unique {
+		span := new(big.Int).Sub(big.NewInt(max), big.NewInt(min))
+		span.Add(span, big.NewInt(1))
+		if span.Cmp(big.NewInt(int64(count))) < 0 {
+			return nil, fmt.Errorf("cannot draw %d unique values from a range of %s", count, span)
+		}
+	}
+
+	values := make([]int64, 0, count)
+	seen := make(map[int64]struct{}, count)
+	for len(values) < count {
+		v := cryptoRandRange(min, max)
+		if unique {
+			if _, dup := seen[v]; dup {
+				continue
+			}
+			seen[v] = struct{}{}
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// generateInts handles GET /int?min=&max=&count=&unique=1
+func generateInts(c *gin.Context) {
+	min, err := queryInt64(c, "min", DefaultIntMin)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	max, err := queryInt64(c, "max", DefaultIntMax)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	count, ok, err := queryInt(c, "count", 1, MaxIntCount)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !ok {
+		count = 1
+	}
+	unique, err := queryBool(c, "unique")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	values, err := RandomInts(min, max, count, unique)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	lines := make([]string, len(values))
+	for i, v := range values {
+		lines[i] = strconv.FormatInt(v, 10)
+	}
+	respondList(c, lines, IntResponse{Min: min, Max: max, Count: count, Unique: unique, Values: values})
+}
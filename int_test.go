@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCryptoRandRange(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		v := cryptoRandRange(-3, 3)
+		assert.True(t, v >= -3 && v <= 3, "Value %d should be in [-3, 3]", v)
+	}
+	// The full int64 range must not overflow
+	cryptoRandRange(math.MinInt64, math.MaxInt64)
+	assert.Equal(t, int64(7), cryptoRandRange(7, 7))
+}
+
+func TestRandomIntsUnique(t *testing.T) {
+	values, err := RandomInts(1, 10, 10, true)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, values, "All values of the range should be drawn once")
+
+	_, err = RandomInts(1, 5, 6, true)
+	assert.Error(t, err, "More unique values than the range holds should be rejected")
+}
+
+func TestIntEndpoint(t *testing.T) {
+	r := setupRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/int?min=-9223372036854775808&max=9223372036854775807&count=5", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp IntResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp.Values, 5)
+
+	req = httptest.NewRequest(http.MethodGet, "/int?min=5&max=1", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
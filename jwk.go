@@ -0,0 +1,134 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// octAlgorithms maps JWA algorithm names to the symmetric key size in bytes and the key use
+var octAlgorithms = map[string]struct {
+	size int
+	use  string
+}{
+	"HS256":   {32, "sig"},
+	"HS384":   {48, "sig"},
+	"HS512":   {64, "sig"},
+	"A128KW":  {16, "enc"},
+	"A192KW":  {24, "enc"},
+	"A256KW":  {32, "enc"},
+	"A128GCM": {16, "enc"},
+	"A192GCM": {24, "enc"},
+	"A256GCM": {32, "enc"},
+}
+
+// jwkKidPattern limits caller-supplied key IDs to URL-safe characters
+var jwkKidPattern = regexp.MustCompile(`^[A-Za-z0-9._~-]{1,64}$`)
+
+// JWK is a JSON Web Key (RFC 7517) with the members of oct, RSA, EC and OKP keys (RFC 7518, RFC 8037)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	K   string `json:"k,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	D   string `json:"d,omitempty"`
+	P   string `json:"p,omitempty"`
+	Q   string `json:"q,omitempty"`
+	DP  string `json:"dp,omitempty"`
+	DQ  string `json:"dq,omitempty"`
+	QI  string `json:"qi,omitempty"`
+}
+
+// JWKSet is a JSON Web Key Set
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// b64url encodes b as unpadded base64url, the encoding of every binary JWK member
+func b64url(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// jwkThumbprint returns the RFC 7638 SHA-256 thumbprint of the required members, base64url encoded
+func jwkThumbprint(members map[string]string) string {
+	// encoding/json sorts map keys, giving the lexicographic order RFC 7638 requires
+	b, _ := json.Marshal(members)
+	sum := sha256.Sum256(b)
+	return b64url(sum[:])
+}
+
+// NewOctJWK returns a random symmetric key sized for alg. The kid defaults to the key's thumbprint.
+func NewOctJWK(alg, kid string) (JWK, error) {
+	spec, ok := octAlgorithms[alg]
+	if !ok {
+		names := make([]string, 0, len(octAlgorithms))
+		for name := range octAlgorithms {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return JWK{}, fmt.Errorf("alg must be one of %s, got %q", strings.Join(names, ", "), alg)
+	}
+	jwk := JWK{Kty: "oct", Kid: kid, Use: spec.use, Alg: alg, K: b64url(cryptoRandBytes(spec.size))}
+	if jwk.Kid == "" {
+		jwk.Kid = jwkThumbprint(map[string]string{"kty": jwk.Kty, "k": jwk.K})
+	}
+	return jwk, nil
+}
+
+// jwkHandler handles GET /jwk?kty=oct&alg=HS256&kid=&count=1&jwks=1
+func jwkHandler(c *gin.Context) {
+	if kty := c.DefaultQuery("kty", "oct"); kty != "oct" {
+		respondError(c, fmt.Errorf("kty must be oct; use /keypair?enc=jwk for RSA, EC and OKP keys, got %q", kty))
+		return
+	}
+	count, ok, err := queryInt(c, "count", 1, MaxBatchCount)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !ok {
+		count = 1
+	}
+	wrap, err := queryBool(c, "jwks")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	kid := c.Query("kid")
+	if kid != "" && !jwkKidPattern.MatchString(kid) {
+		respondError(c, fmt.Errorf("kid must be 1-64 letters, digits or ._~- characters"))
+		return
+	}
+	if kid != "" && count > 1 {
+		respondError(c, fmt.Errorf("kid cannot be combined with count > 1"))
+		return
+	}
+
+	alg := strings.ToUpper(c.DefaultQuery("alg", "HS256"))
+	set := JWKSet{Keys: make([]JWK, count)}
+	for i := range set.Keys {
+		if set.Keys[i], err = NewOctJWK(alg, kid); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+	c.Header("Cache-Control", "no-store, no-cache, must-revalidate")
+	if wrap || count > 1 {
+		c.IndentedJSON(http.StatusOK, set)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, set.Keys[0])
+}
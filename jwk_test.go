@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWKThumbprint(t *testing.T) {
+	// RFC 7638 section 3.1 example
+	members := map[string]string{
+		"kty": "RSA",
+		"e":   "AQAB",
+		"n": "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMs" +
+			"tn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n" +
+			"91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+	}
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", jwkThumbprint(members))
+}
+
+func TestJWKEndpoint(t *testing.T) {
+	r := setupRouter()
+
+	tests := []struct {
+		query string
+		alg   string
+		use   string
+		size  int
+		count int // number of keys; a set is returned when count > 1 or jwks=1
+		set   bool
+		kid   string // expected kid, or "" for the RFC 7638 thumbprint
+	}{
+		{"", "HS256", "sig", 32, 1, false, ""},
+		{"alg=A256GCM", "A256GCM", "enc", 32, 1, false, ""},
+		{"alg=a128kw", "A128KW", "enc", 16, 1, false, ""},
+		{"alg=HS512&count=3", "HS512", "sig", 64, 3, true, ""},
+		{"kid=2026-10&jwks=1", "HS256", "sig", 32, 1, true, "2026-10"},
+	}
+	for _, tt := range tests {
+		w := serveRequest(r, http.MethodGet, "/jwk?"+tt.query, nil)
+		if !assert.Equal(t, http.StatusOK, w.Code, "Query %q", tt.query) {
+			continue
+		}
+		var set JWKSet
+		if tt.set {
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &set))
+		} else {
+			set.Keys = make([]JWK, 1)
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &set.Keys[0]))
+		}
+		if !assert.Len(t, set.Keys, tt.count, "Query %q", tt.query) {
+			continue
+		}
+		for _, jwk := range set.Keys {
+			assert.Equal(t, "oct", jwk.Kty)
+			assert.Equal(t, tt.alg, jwk.Alg)
+			assert.Equal(t, tt.use, jwk.Use)
+			key, err := base64.RawURLEncoding.DecodeString(jwk.K)
+			assert.NoError(t, err)
+			assert.Len(t, key, tt.size)
+			kid := tt.kid
+			if kid == "" {
+				kid = jwkThumbprint(map[string]string{"kty": "oct", "k": jwk.K})
+			}
+			assert.Equal(t, kid, jwk.Kid)
+		}
+	}
+
+	for _, query := range []string{
+		"kty=RSA", "alg=HS1", "alg=none", "kid=a%20b", "kid=" + strings.Repeat("k", 65), "kid=x&count=2",
+		"count=0", "count=101", "jwks=yes",
+	} {
+		w := serveRequest(r, http.MethodGet, "/jwk?"+query, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Query %q should be rejected", query)
+	}
+}
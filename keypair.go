@@ -0,0 +1,227 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultRSABits = 3072
+	// MaxKeypairCount is lower than MaxBatchCount because RSA generation is slow
+	MaxKeypairCount = 10
+)
+
+// maxRSAKeysPerRequest bounds the RSA keys one request may generate. Generation time
+// grows roughly with the cube of the modulus size, so larger keys get a smaller
+// allowance and no request spends more than a few seconds of CPU.
+var maxRSAKeysPerRequest = map[int]int{2048: MaxKeypairCount, 3072: 2, 4096: 1}
+
+// KeySpec selects the type and size of an asymmetric key
+type KeySpec struct {
+	Type  string // rsa, ec or ed25519
+	Bits  int    // RSA modulus size
+	Curve string // P-256 or P-384 for ec
+}
+
+// KeypairResponse is the JSON payload returned by /keypair
+type KeypairResponse struct {
+	Type  string    `json:"type"`
+	Bits  int       `json:"bits,omitempty"`
+	Curve string    `json:"curve,omitempty"`
+	Keys  []Keypair `json:"keys"`
+}
+
+// Keypair is one generated key pair as PEM (PKCS#8 and SPKI) or as JWKs
+type Keypair struct {
+	PrivateKey string `json:"private_key,omitempty"`
+	PublicKey  string `json:"public_key,omitempty"`
+	PrivateJWK *JWK   `json:"private_jwk,omitempty"`
+	PublicJWK  *JWK   `json:"public_jwk,omitempty"`
+}
+
+// ecCurves maps the supported curve names to their implementation and JWS algorithm
+var ecCurves = map[string]struct {
+	curve elliptic.Curve
+	alg   string
+}{
+	"P-256": {elliptic.P256(), "ES256"},
+	"P-384": {elliptic.P384(), "ES384"},
+}
+
+// parseKeySpec reads the key type and size from type=, bits= and curve=
+func parseKeySpec(c *gin.Context, defaultType string) (KeySpec, error) {
+	spec := KeySpec{Type: strings.ToLower(c.DefaultQuery("type", defaultType))}
+	switch spec.Type {
+	case "rsa":
+		bits, ok, err := queryInt(c, "bits", 2048, 4096)
+		if err != nil {
+			return spec, err
+		}
+		if !ok {
+			bits = DefaultRSABits
+		}
+		if bits%1024 != 0 {
+			return spec, fmt.Errorf("bits must be 2048, 3072 or 4096, got %d", bits)
+		}
+		spec.Bits = bits
+	case "ec":
+		spec.Curve = strings.ToUpper(c.DefaultQuery("curve", "P-256"))
+		if _, ok := ecCurves[spec.Curve]; !ok {
+			return spec, fmt.Errorf("curve must be P-256 or P-384, got %q", spec.Curve)
+		}
+	case "ed25519":
+	default:
+		return spec, fmt.Errorf("type must be one of rsa, ec, ed25519, got %q", spec.Type)
+	}
+	return spec, nil
+}
+
+// checkCost rejects generating n keys of the spec in one request when that exceeds
+// maxRSAKeysPerRequest; EC and Ed25519 keys are cheap and always allowed
+func (s KeySpec) checkCost(n int) error {
+	if max, ok := maxRSAKeysPerRequest[s.Bits]; s.Type == "rsa" && ok && n > max {
+		return fmt.Errorf("at most %d RSA keys of %d bits can be generated per request, this needs %d", max, s.Bits, n)
+	}
+	return nil
+}
+
+// Generate creates a new private key for the spec
+func (s KeySpec) Generate() (crypto.Signer, error) {
+	switch s.Type {
+	case "rsa":
+		return rsa.GenerateKey(rand.Reader, s.Bits)
+	case "ec":
+		return ecdsa.GenerateKey(ecCurves[s.Curve].curve, rand.Reader)
+	case "ed25519":
+		return ed25519.NewKeyFromSeed(cryptoRandBytes(ed25519.SeedSize)), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", s.Type)
+	}
+}
+
+// EncodeKeypairPEM returns the PKCS#8 private key and SPKI public key as PEM blocks
+func EncodeKeypairPEM(key crypto.Signer) (string, string, error) {
+	priv, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	pub, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return "", "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: priv})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub})), nil
+}
+
+// EncodeKeypairJWK returns the private and public JWKs of key; both share the thumbprint kid
+func EncodeKeypairJWK(key crypto.Signer) (JWK, JWK, error) {
+	var priv, pub JWK
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		pub = JWK{Kty: "RSA", Alg: "RS256",
+			N: b64url(k.N.Bytes()), E: b64url(big.NewInt(int64(k.E)).Bytes())}
+		priv = pub
+		priv.D = b64url(k.D.Bytes())
+		priv.P, priv.Q = b64url(k.Primes[0].Bytes()), b64url(k.Primes[1].Bytes())
+		priv.DP, priv.DQ = b64url(k.Precomputed.Dp.Bytes()), b64url(k.Precomputed.Dq.Bytes())
+		priv.QI = b64url(k.Precomputed.Qinv.Bytes())
+		pub.Kid = jwkThumbprint(map[string]string{"kty": pub.Kty, "n": pub.N, "e": pub.E})
+	case *ecdsa.PrivateKey:
+		ecdhKey, err := k.ECDH()
+		if err != nil {
+			return priv, pub, err
+		}
+		// Uncompressed point: 0x04 || X || Y, each padded to the field size
+		point := ecdhKey.PublicKey().Bytes()
+		size := (len(point) - 1) / 2
+		pub = JWK{Kty: "EC", Crv: k.Curve.Params().Name, Alg: ecCurves[k.Curve.Params().Name].alg,
+			X: b64url(point[1 : 1+size]), Y: b64url(point[1+size:])}
+		priv = pub
+		priv.D = b64url(ecdhKey.Bytes())
+		pub.Kid = jwkThumbprint(map[string]string{"kty": pub.Kty, "crv": pub.Crv, "x": pub.X, "y": pub.Y})
+	case ed25519.PrivateKey:
+		pub = JWK{Kty: "OKP", Crv: "Ed25519", Alg: "EdDSA", X: b64url(k.Public().(ed25519.PublicKey))}
+		priv = pub
+		priv.D = b64url(k.Seed())
+		pub.Kid = jwkThumbprint(map[string]string{"kty": pub.Kty, "crv": pub.Crv, "x": pub.X})
+	default:
+		return priv, pub, fmt.Errorf("unsupported key type %T", key)
+	}
+	pub.Use = "sig"
+	priv.Use, priv.Kid = pub.Use, pub.Kid
+	return priv, pub, nil
+}
+
+// keypairHandler handles GET /keypair?type=rsa|ec|ed25519&bits=3072&curve=P-256&enc=pem|jwk&count=1
+func keypairHandler(c *gin.Context) {
+	spec, err := parseKeySpec(c, "ed25519")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	enc := strings.ToLower(c.DefaultQuery("enc", "pem"))
+	if enc != "pem" && enc != "jwk" {
+		respondError(c, fmt.Errorf("enc must be pem or jwk, got %q", enc))
+		return
+	}
+	count, ok, err := queryInt(c, "count", 1, MaxKeypairCount)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !ok {
+		count = 1
+	}
+	if err := spec.checkCost(count); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	resp := KeypairResponse{Type: spec.Type, Bits: spec.Bits, Curve: spec.Curve, Keys: make([]Keypair, count)}
+	var lines []string
+	for i := range resp.Keys {
+		key, err := spec.Generate()
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		if resp.Keys[i], err = encodeKeypair(key, enc); err != nil {
+			respondError(c, err)
+			return
+		}
+		lines = append(lines, keypairLines(resp.Keys[i])...)
+	}
+	respondList(c, lines, resp)
+}
+
+// encodeKeypair encodes key as PEM or JWK
+func encodeKeypair(key crypto.Signer, enc string) (Keypair, error) {
+	if enc == "jwk" {
+		priv, pub, err := EncodeKeypairJWK(key)
+		return Keypair{PrivateJWK: &priv, PublicJWK: &pub}, err
+	}
+	priv, pub, err := EncodeKeypairPEM(key)
+	return Keypair{PrivateKey: priv, PublicKey: pub}, err
+}
+
+// keypairLines renders a key pair for plain-text clients: PEM blocks or one compact JWK per line
+func keypairLines(k Keypair) []string {
+	if k.PrivateJWK != nil {
+		priv, _ := json.Marshal(k.PrivateJWK)
+		pub, _ := json.Marshal(k.PublicJWK)
+		return []string{string(priv), string(pub)}
+	}
+	return []string{strings.TrimSuffix(k.PrivateKey, "\n"), strings.TrimSuffix(k.PublicKey, "\n")}
+}
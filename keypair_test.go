@@ -0,0 +1,130 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeypairEndpoint(t *testing.T) {
+	r := setupRouter()
+
+	tests := []struct {
+		query string
+		typ   string
+		bits  int
+		curve string
+		count int
+		jwk   bool
+	}{
+		{"", "ed25519", 0, "", 1, false},
+		{"type=ed25519&count=2", "ed25519", 0, "", 2, false},
+		{"type=ec&curve=P-384", "ec", 0, "P-384", 1, false},
+		{"type=ec&enc=jwk&count=10", "ec", 0, "P-256", 10, true},
+		{"type=rsa&bits=2048", "rsa", 2048, "", 1, false},
+		{"type=rsa&bits=2048&enc=jwk", "rsa", 2048, "", 1, true},
+	}
+	for _, tt := range tests {
+		w := serveRequest(r, http.MethodGet, "/keypair?"+tt.query, nil)
+		if !assert.Equal(t, http.StatusOK, w.Code, "Query %q", tt.query) {
+			continue
+		}
+		var resp KeypairResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, tt.typ, resp.Type)
+		assert.Equal(t, tt.bits, resp.Bits)
+		assert.Equal(t, tt.curve, resp.Curve)
+		assert.Len(t, resp.Keys, tt.count)
+		for _, k := range resp.Keys {
+			if tt.jwk {
+				if assert.NotNil(t, k.PrivateJWK) && assert.NotNil(t, k.PublicJWK) {
+					assert.Equal(t, k.PublicJWK.Kid, k.PrivateJWK.Kid)
+				}
+				assert.Empty(t, k.PrivateKey)
+				continue
+			}
+			privBlock, _ := pem.Decode([]byte(k.PrivateKey))
+			pubBlock, _ := pem.Decode([]byte(k.PublicKey))
+			if !assert.NotNil(t, privBlock) || !assert.NotNil(t, pubBlock) {
+				continue
+			}
+			assert.Equal(t, "PRIVATE KEY", privBlock.Type)
+			assert.Equal(t, "PUBLIC KEY", pubBlock.Type)
+			priv, err := x509.ParsePKCS8PrivateKey(privBlock.Bytes)
+			assert.NoError(t, err)
+			pub, err := x509.ParsePKIXPublicKey(pubBlock.Bytes)
+			assert.NoError(t, err)
+			assert.True(t, priv.(crypto.Signer).Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(pub),
+				"Public key should match the private key for %q", tt.query)
+			if key, ok := priv.(*rsa.PrivateKey); ok {
+				assert.Equal(t, tt.bits, key.N.BitLen())
+			}
+		}
+	}
+
+	for _, query := range []string{
+		"type=dsa", "type=rsa&bits=1024", "type=rsa&bits=2500", "type=rsa&bits=8192", "type=ec&curve=P-521",
+		"enc=der", "count=0", "count=11", "type=rsa&bits=2048&count=11",
+		"type=rsa&bits=3072&count=3", "type=rsa&count=3", "type=rsa&bits=4096&count=2", "type=rsa&bits=4096&count=10",
+	} {
+		w := serveRequest(r, http.MethodGet, "/keypair?"+query, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Query %q should be rejected", query)
+	}
+}
+
+func TestKeySpecCost(t *testing.T) {
+	for bits, max := range maxRSAKeysPerRequest {
+		spec := KeySpec{Type: "rsa", Bits: bits}
+		assert.NoError(t, spec.checkCost(max), "%d keys of %d bits should be allowed", max, bits)
+		assert.Error(t, spec.checkCost(max+1), "%d keys of %d bits should be rejected", max+1, bits)
+	}
+	assert.NoError(t, KeySpec{Type: "ec", Curve: "P-384"}.checkCost(MaxKeypairCount))
+	assert.NoError(t, KeySpec{Type: "ed25519"}.checkCost(MaxKeypairCount))
+}
+
+func TestKeypairJWK(t *testing.T) {
+	for _, spec := range []KeySpec{{Type: "rsa", Bits: 2048}, {Type: "ec", Curve: "P-256"}, {Type: "ed25519"}} {
+		key, err := spec.Generate()
+		if !assert.NoError(t, err) {
+			continue
+		}
+		priv, pub, err := EncodeKeypairJWK(key)
+		assert.NoError(t, err)
+		assert.Equal(t, pub.Kid, priv.Kid)
+		assert.NotEmpty(t, priv.D)
+		assert.Empty(t, pub.D, "Public JWK must not contain private members")
+		assert.Empty(t, pub.P)
+
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			assert.Equal(t, "RS256", pub.Alg)
+			assert.Equal(t, "AQAB", pub.E)
+			assert.Equal(t, k.N, new(big.Int).SetBytes(decodeB64URL(t, pub.N)))
+		case *ecdsa.PrivateKey:
+			assert.Equal(t, "ES256", pub.Alg)
+			assert.Len(t, decodeB64URL(t, pub.X), 32, "Coordinates should be padded to the field size")
+			assert.Len(t, decodeB64URL(t, pub.Y), 32)
+			assert.Len(t, decodeB64URL(t, priv.D), 32)
+		case ed25519.PrivateKey:
+			assert.Equal(t, "OKP", pub.Kty)
+			assert.Equal(t, []byte(k.Public().(ed25519.PublicKey)), decodeB64URL(t, pub.X))
+			assert.Equal(t, k.Seed(), decodeB64URL(t, priv.D))
+		}
+	}
+}
+
+func decodeB64URL(t *testing.T, s string) []byte {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	assert.NoError(t, err)
+	return b
+}
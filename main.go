@@ -3,15 +3,20 @@ package main
 import (
 	"context"
 	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"html/template"
 	"log"
+	"math"
 	"math/big"
 	"net/http"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
@@ -41,6 +46,15 @@ var cliSignaturesMap = map[string]struct{}{
 
 const MaxAllowedLength = 100
 
+// MaxBatchCount caps the count= parameter of the batch endpoints
+const MaxBatchCount = 100
+
+// DefaultAlphanumericCharset is the alphabet used when the caller does not supply one
+const DefaultAlphanumericCharset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// DefaultSymbols is the set of non-alphanumeric, printable characters used in printable strings
+const DefaultSymbols = "!#$%*+-=?@^_"
+
 // cryptoRandInt generates a cryptographically secure random integer in the range [0, max)
 func cryptoRandInt(max int) int {
 	if max <= 0 {
@@ -53,6 +67,35 @@ func cryptoRandInt(max int) int {
 	return int(n.Int64())
 }
 
+// cryptoRandRange generates a cryptographically secure random integer in the inclusive
+// range [min, max]; the span is computed with big integers so the full int64 range works.
+func cryptoRandRange(min, max int64) int64 {
+	if max <= min {
+		return min
+	}
+	span := new(big.Int).Sub(big.NewInt(max), big.NewInt(min))
+	span.Add(span, big.NewInt(1))
+	n, err := rand.Int(rand.Reader, span)
+	if err != nil {
+		log.Fatalf("crypto/rand failed: %v", err)
+	}
+	return n.Add(n, big.NewInt(min)).Int64()
+}
+
+// cryptoRandFloat64 generates a cryptographically secure random float64 in the range [0, 1)
+func cryptoRandFloat64() float64 {
+	return float64(cryptoRandRange(0, 1<<53-1)) / (1 << 53)
+}
+
+// cryptoRandBytes returns n bytes read from the same crypto source as cryptoRandInt
+func cryptoRandBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatalf("crypto/rand failed: %v", err)
+	}
+	return b
+}
+
 // parseLengths extracts and clamps printable and alphanumeric lengths from the request
 func parseLengths(c *gin.Context) (int, int) {
 	printableLength := cryptoRandInt(19) + 12    // Random length between 12 and 30
@@ -85,18 +128,252 @@ func parseLengths(c *gin.Context) (int, int) {
 	return printableLength, alphanumericLength
 }
 
+// StringOptions holds the optional generator settings parsed from the query string
+type StringOptions struct {
+	Charset     []rune // alphabet for the alphanumeric string; nil means DefaultAlphanumericCharset
+	Preset      string // name of the charset preset Charset was taken from, if any
+	Unambiguous bool   // drop visually confusable characters (see AmbiguousChars)
+	Policy      *Policy
+	Symbols     []rune // symbol pool for the printable string; nil means DefaultSymbols
+	SymMin      int    // minimum number of symbols inserted into the printable string
+	SymMax      int    // maximum number of symbols inserted into the printable string
+	SymCountSet bool   // SymMin/SymMax were supplied; otherwise 1–3 symbols are inserted
+
+	Pronounceable bool // build the alphanumeric string from consonant-vowel syllables
+	Capitalize    bool // pronounceable mode: capitalize the first letter
+	Digits        int  // pronounceable mode: number of digits appended to the syllables
+
+	Mask string // template for the optional mask string, see MaskPlaceholders
+}
+
+// queryInt parses an optional integer query parameter and checks it lies in [min, max]
+func queryInt(c *gin.Context, key string, min, max int) (int, bool, error) {
+	val, ok := c.GetQuery(key)
+	if !ok {
+		return 0, false, nil
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n < min || n > max {
+		return 0, false, fmt.Errorf("%s must be an integer between %d and %d, got %q", key, min, max, val)
+	}
+	return n, true, nil
+}
+
+// queryBool parses an optional boolean query parameter such as unambiguous=1
+func queryBool(c *gin.Context, key string) (bool, error) {
+	val, ok := c.GetQuery(key)
+	if !ok || val == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, fmt.Errorf("%s must be a boolean (0/1/true/false), got %q", key, val)
+	}
+	return b, nil
+}
+
+// parseOptions extracts the optional generator settings from the request
+func parseOptions(c *gin.Context) (StringOptions, error) {
+	var opts StringOptions
+	if val, ok := c.GetQuery("charset"); ok {
+		charset, err := ParseCharset(val)
+		if err != nil {
+			return opts, err
+		}
+		opts.Charset = charset
+	}
+	if name, ok := c.GetQuery("preset"); ok {
+		if opts.Charset != nil {
+			return opts, fmt.Errorf("charset and preset cannot be combined")
+		}
+		preset, err := LookupPreset(name)
+		if err != nil {
+			return opts, err
+		}
+		opts.Charset = []rune(preset.Alphabet)
+		opts.Preset = preset.Name
+	}
+	unambiguous, err := queryBool(c, "unambiguous")
+	if err != nil {
+		return opts, err
+	}
+	opts.Unambiguous = unambiguous
+	if err := parseSymbolOptions(c, &opts); err != nil {
+		return opts, err
+	}
+	if err := parsePronounceableOptions(c, &opts); err != nil {
+		return opts, err
+	}
+	if err := parsePolicyOption(c, &opts); err != nil {
+		return opts, err
+	}
+	if err := parseMaskOption(c, &opts); err != nil {
+		return opts, err
+	}
+	if len(opts.alphabet()) < 2 {
+		return opts, fmt.Errorf("charset must contain at least 2 distinct characters once look-alike characters are removed")
+	}
+	return opts, nil
+}
+
+// parseSymbolOptions reads the symbols, symcount, symmin and symmax query parameters
+func parseSymbolOptions(c *gin.Context, opts *StringOptions) error {
+	if val, ok := c.GetQuery("symbols"); ok {
+		symbols, err := ParseSymbols(val)
+		if err != nil {
+			return err
+		}
+		opts.Symbols = symbols
+	}
+
+	count, hasCount, err := queryInt(c, "symcount", 0, MaxAllowedLength)
+	if err != nil {
+		return err
+	}
+	symMin, hasMin, err := queryInt(c, "symmin", 0, MaxAllowedLength)
+	if err != nil {
+		return err
+	}
+	symMax, hasMax, err := queryInt(c, "symmax", 0, MaxAllowedLength)
+	if err != nil {
+		return err
+	}
+	switch {
+	case hasCount && (hasMin || hasMax):
+		return fmt.Errorf("symcount cannot be combined with symmin or symmax")
+	case hasCount:
+		opts.SymMin, opts.SymMax, opts.SymCountSet = count, count, true
+	case hasMin || hasMax:
+		if !hasMax {
+			symMax = MaxAllowedLength
+		}
+		if symMin > symMax {
+			return fmt.Errorf("symmin (%d) must not exceed symmax (%d)", symMin, symMax)
+		}
+		opts.SymMin, opts.SymMax, opts.SymCountSet = symMin, symMax, true
+	}
+	return nil
+}
+
+// alphabet returns the alphanumeric alphabet after applying the charset and unambiguous options
+func (o StringOptions) alphabet() []rune {
+	charset := o.Charset
+	if charset == nil {
+		charset = []rune(DefaultAlphanumericCharset)
+	}
+	if o.Unambiguous {
+		charset = RemoveAmbiguous(charset)
+	}
+	return charset
+}
+
+// alphanumericString generates the alphanumeric string honoring the options
+func alphanumericString(length int, opts StringOptions) RandomString {
+	if opts.Pronounceable {
+		s, entropy := GeneratePronounceable(length, opts)
+		return RandomString{Length: length, String: s, Entropy: entropy}
+	}
+	result := RandomString{
+		Length: length,
+		String: GenerateRandomAlphanumericWithOptions(length, opts),
+	}
+	if opts.Preset != "" {
+		alphabet := opts.alphabet()
+		bitsPerChar := charsetPresets[opts.Preset].BitsPerChar
+		if len(alphabet) != len(opts.Charset) {
+			bitsPerChar = math.Log2(float64(len(alphabet)))
+		}
+		result.Preset = opts.Preset
+		result.Entropy = math.Round(bitsPerChar*float64(length)*100) / 100
+	} else if opts.Charset != nil {
+		result.Charset = string(opts.alphabet())
+	}
+	return result
+}
+
+// printableString generates the printable string, enforcing the password policy when one is set
+func printableString(length int, opts StringOptions) (RandomString, error) {
+	if opts.Policy != nil {
+		s, err := opts.Policy.Generate(length, opts.Unambiguous)
+		if err != nil {
+			return RandomString{}, err
+		}
+		symbols := 0
+		for _, r := range s {
+			if strings.ContainsRune(opts.Policy.Symbols, r) {
+				symbols++
+			}
+		}
+		return RandomString{Length: length, String: s, Policy: opts.Policy, Symbols: &symbols}, nil
+	}
+	if opts.SymCountSet && opts.SymMin > length {
+		return RandomString{}, fmt.Errorf("at least %d symbols requested but length is %d", opts.SymMin, length)
+	}
+	s, symbols := generatePrintable(length, opts)
+	return RandomString{Length: length, String: s, Symbols: &symbols}, nil
+}
+
 // buildResponse creates the Response payload for JSON responses
-func buildResponse(printableLength, alphanumericLength int) Response {
-	return Response{
-		Printable: RandomString{
-			Length: printableLength,
-			String: GenerateRandomPrintable(printableLength),
-		},
-		AlphaNumeric: RandomString{
-			Length: alphanumericLength,
-			String: GenerateRandomAlphanumeric(alphanumericLength),
-		},
+func buildResponse(printableLength, alphanumericLength int, opts StringOptions) (Response, error) {
+	printable, err := printableString(printableLength, opts)
+	if err != nil {
+		return Response{}, err
+	}
+	response := Response{
+		Printable:    printable,
+		AlphaNumeric: alphanumericString(alphanumericLength, opts),
+	}
+	if opts.Mask != "" {
+		s, entropy := GenerateFromMask(opts.Mask, opts.Unambiguous)
+		response.Mask = &RandomString{Length: utf8.RuneCountInString(s), String: s, Entropy: entropy}
+	}
+	return response, nil
+}
+
+// wantsJSON reports whether the request should be answered with JSON instead of HTML;
+// only browsers requesting the / page get HTML.
+func wantsJSON(c *gin.Context) bool {
+	return c.Request.URL.Path != "/" || isCLIUserAgent(c.GetHeader("User-Agent"))
+}
+
+// respondError writes a 400 error as JSON or plain text depending on the client
+func respondError(c *gin.Context, err error) {
+	c.Header("Cache-Control", "no-store, no-cache, must-revalidate")
+	if wantsJSON(c) {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	c.String(http.StatusBadRequest, err.Error())
+}
+
+// wantsPlainText reports whether a list endpoint should answer with plain text:
+// format=text forces it, format=json disables it, and CLI clients get text by default.
+func wantsPlainText(c *gin.Context) (bool, error) {
+	switch format := c.Query("format"); format {
+	case "":
+		return isCLIUserAgent(c.GetHeader("User-Agent")), nil
+	case "text":
+		return true, nil
+	case "json":
+		return false, nil
+	default:
+		return false, fmt.Errorf("format must be json or text, got %q", format)
+	}
+}
+
+// respondList writes items one per line for plain-text clients, or payload as JSON
+func respondList(c *gin.Context, items []string, payload interface{}) {
+	text, err := wantsPlainText(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store, no-cache, must-revalidate")
+	if text {
+		c.String(http.StatusOK, strings.Join(items, "\n")+"\n")
+		return
+	}
+	c.IndentedJSON(http.StatusOK, payload)
 }
 
 // isCLIUserAgent returns true when the provided user-agent string matches common CLI clients
@@ -122,74 +399,255 @@ var (
 
 // RandomString struct for individual random strings
 type RandomString struct {
-	Length int    `json:"length"`
-	String string `json:"string"`
+	Length  int     `json:"length"`
+	String  string  `json:"string"`
+	Charset string  `json:"charset,omitempty"`
+	Preset  string  `json:"preset,omitempty"`
+	Entropy float64 `json:"entropy_bits,omitempty"`
+	Policy  *Policy `json:"policy,omitempty"`
+	Symbols *int    `json:"symbols,omitempty"` // inserted symbols; set (even to 0) for the printable string only
 }
 
 // Response struct for JSON response
 type Response struct {
-	Printable    RandomString `json:"printable"`
-	AlphaNumeric RandomString `json:"alphanumeric"`
+	Printable    RandomString  `json:"printable"`
+	AlphaNumeric RandomString  `json:"alphanumeric"`
+	Passphrase   *Passphrase   `json:"passphrase,omitempty"`
+	Mask         *RandomString `json:"mask,omitempty"`
 }
 
 // Function to generate random printable string
 func GenerateRandomPrintable(length int) string {
+	return GenerateRandomPrintableWithOptions(length, StringOptions{})
+}
+
+// GenerateRandomPrintableWithOptions generates a printable string honoring the
+// Unambiguous and symbol options; a custom charset is used for the alphanumeric string only.
+func GenerateRandomPrintableWithOptions(length int, opts StringOptions) string {
+	s, _ := generatePrintable(length, opts)
+	return s
+}
+
+// generatePrintable generates a printable string and returns it together with
+// the number of symbols that were inserted.
+func generatePrintable(length int, opts StringOptions) (string, int) {
 	if length <= 0 {
-		return ""
+		return "", 0
 	}
 	if length > MaxAllowedLength {
 		length = MaxAllowedLength
 	}
 
 	// First, generate a standard alphanumeric string.
-	result := GenerateRandomAlphanumeric(length)
+	result := GenerateRandomAlphanumericWithOptions(length, StringOptions{Unambiguous: opts.Unambiguous})
 	runes := []rune(result)
 
 	// Define the set of non-alphanumeric, printable characters.
-	specialChars := []rune("!#$%*+-=?@^_")
+	specialChars := opts.Symbols
+	if specialChars == nil {
+		specialChars = []rune(DefaultSymbols)
+	}
+	if opts.Unambiguous {
+		specialChars = RemoveAmbiguous(specialChars)
+	}
 
-	// Determine how many characters to replace (1 to 3, but not more than the string length).
-	numReplacements := cryptoRandInt(3) + 1
-	if numReplacements >= length {
-		numReplacements = 1
+	// Determine how many characters to replace: the requested range, or 1 to 3
+	// by default (but not more than the string length).
+	var numReplacements int
+	if opts.SymCountSet {
+		numReplacements = opts.SymMin + cryptoRandInt(opts.SymMax-opts.SymMin+1)
+		if numReplacements > length {
+			numReplacements = length
+		}
+	} else {
+		numReplacements = cryptoRandInt(3) + 1
+		if numReplacements >= length {
+			numReplacements = 1
+		}
 	}
 
 	// If there are no special characters, we can't do replacements.
 	if len(specialChars) == 0 {
-		return string(runes)
+		return string(runes), 0
 	}
 
-	// Replace characters at random positions.
-	for i := 0; i < numReplacements; i++ {
-		pos := cryptoRandInt(length)
+	// Replace characters at distinct random positions.
+	for _, pos := range randomPositions(length, numReplacements) {
 		runes[pos] = specialChars[cryptoRandInt(len(specialChars))]
 	}
 
-	return string(runes)
+	return string(runes), numReplacements
+}
+
+// randomPositions returns k distinct indexes drawn uniformly from [0, n) using a partial Fisher-Yates shuffle
+func randomPositions(n, k int) []int {
+	if k > n {
+		k = n
+	}
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	for i := 0; i < k; i++ {
+		j := i + cryptoRandInt(n-i)
+		idx[i], idx[j] = idx[j], idx[i]
+	}
+	return idx[:k]
 }
 
 // Function to generate random alphanumeric string
 func GenerateRandomAlphanumeric(length int) string {
+	return GenerateRandomAlphanumericWithOptions(length, StringOptions{})
+}
+
+// GenerateRandomAlphanumericWithOptions generates a string from the charset selected by opts
+func GenerateRandomAlphanumericWithOptions(length int, opts StringOptions) string {
 	if length <= 0 {
 		return ""
 	}
 	if length > MaxAllowedLength {
 		length = MaxAllowedLength
 	}
-	letters := []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
+	return GenerateRandomFromCharset(length, opts.alphabet())
+}
+
+// AmbiguousChars lists characters that are easily confused when read aloud or
+// retyped from a screenshot (0/O, 1/l/I, 5/S).
+const AmbiguousChars = "0O1lI5S"
+
+// RemoveAmbiguous returns charset without the characters listed in AmbiguousChars
+func RemoveAmbiguous(charset []rune) []rune {
+	filtered := make([]rune, 0, len(charset))
+	for _, r := range charset {
+		if !strings.ContainsRune(AmbiguousChars, r) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// CharsetPreset is a named alphabet together with its entropy per character in bits
+type CharsetPreset struct {
+	Name        string
+	Alphabet    string
+	BitsPerChar float64
+}
+
+// charsetPresets is the registry of named alphabets accepted by the preset query parameter
+var charsetPresets = map[string]CharsetPreset{
+	// Lowercase hexadecimal, 16 symbols
+	"hex": {Name: "hex", Alphabet: "0123456789abcdef", BitsPerChar: 4},
+	// RFC 4648 base32 alphabet, 32 symbols
+	"base32": {Name: "base32", Alphabet: "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567", BitsPerChar: 5},
+	// Bitcoin base58 alphabet (no 0, O, I, l), 58 symbols
+	"base58": {Name: "base58", Alphabet: "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz", BitsPerChar: 5.8580},
+	// RFC 4648 URL-safe base64 alphabet, 64 symbols
+	"base64url": {Name: "base64url", Alphabet: "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_", BitsPerChar: 6},
+	// Crockford base32 (no I, L, O, U), 32 symbols
+	"crockford": {Name: "crockford", Alphabet: CrockfordAlphabet, BitsPerChar: 5},
+	// Decimal digits, 10 symbols
+	"digits": {Name: "digits", Alphabet: "0123456789", BitsPerChar: 3.3219},
+	// Lowercase ASCII letters, 26 symbols
+	"lower": {Name: "lower", Alphabet: "abcdefghijklmnopqrstuvwxyz", BitsPerChar: 4.7004},
+	// Uppercase ASCII letters, 26 symbols
+	"upper": {Name: "upper", Alphabet: "ABCDEFGHIJKLMNOPQRSTUVWXYZ", BitsPerChar: 4.7004},
+}
+
+// LookupPreset returns the charset preset with the given (case-insensitive) name
+func LookupPreset(name string) (CharsetPreset, error) {
+	preset, ok := charsetPresets[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		names := make([]string, 0, len(charsetPresets))
+		for n := range charsetPresets {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return CharsetPreset{}, fmt.Errorf("unknown preset %q (valid: %s)", name, strings.Join(names, ", "))
+	}
+	return preset, nil
+}
+
+// GenerateRandomFromCharset generates a random string of length runes drawn uniformly from charset
+func GenerateRandomFromCharset(length int, charset []rune) string {
+	if length <= 0 || len(charset) == 0 {
+		return ""
+	}
+	if length > MaxAllowedLength {
+		length = MaxAllowedLength
+	}
 	result := make([]rune, length)
 	for i := range result {
-		result[i] = letters[cryptoRandInt(len(letters))]
+		result[i] = charset[cryptoRandInt(len(charset))]
 	}
 	return string(result)
 }
 
+// ParseCharset validates a caller supplied alphabet and removes duplicate runes,
+// keeping the order of first appearance so every distinct rune is equally likely.
+func ParseCharset(s string) ([]rune, error) {
+	if !utf8.ValidString(s) {
+		return nil, fmt.Errorf("charset is not valid UTF-8")
+	}
+	seen := make(map[rune]struct{}, len(s))
+	charset := make([]rune, 0, len(s))
+	for _, r := range s {
+		if !unicode.IsPrint(r) || unicode.IsSpace(r) {
+			return nil, fmt.Errorf("charset contains non-printable or whitespace character %U", r)
+		}
+		if _, dup := seen[r]; dup {
+			continue
+		}
+		seen[r] = struct{}{}
+		charset = append(charset, r)
+	}
+	switch len(charset) {
+	case 0:
+		return nil, fmt.Errorf("charset must not be empty")
+	case 1:
+		return nil, fmt.Errorf("charset must contain at least 2 distinct characters, got %q", string(charset))
+	}
+	return charset, nil
+}
+
 func generateStrings(c *gin.Context) {
 	printableLength, alphanumericLength := parseLengths(c)
+	opts, err := parseOptions(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	response, err := buildResponse(printableLength, alphanumericLength, opts)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
 
-	ua := c.GetHeader("User-Agent")
-	if c.Request.URL.Path == "/json" || isCLIUserAgent(ua) {
-		response := buildResponse(printableLength, alphanumericLength)
+	if kind, ok := qrFormat(c); ok {
+		content, err := qrContent(c, response)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		respondQR(c, content, kind)
+		return
+	}
+
+	if wantsJSON(c) {
+		withPassphrase, err := wantsPassphrase(c)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		if withPassphrase {
+			passphraseOpts, err := parsePassphraseOptions(c)
+			if err != nil {
+				respondError(c, err)
+				return
+			}
+			passphrase := GeneratePassphrase(passphraseOpts)
+			response.Passphrase = &passphrase
+		}
 		c.Header("Cache-Control", "no-store, no-cache, must-revalidate")
 		c.IndentedJSON(http.StatusOK, response)
 		return
@@ -204,9 +662,12 @@ func generateStrings(c *gin.Context) {
 
 	data := map[string]interface{}{
 		"PrintableLength":    printableLength,
-		"PrintableString":    GenerateRandomPrintable(printableLength),
+		"PrintableString":    response.Printable.String,
 		"AlphanumericLength": alphanumericLength,
-		"AlphanumericString": GenerateRandomAlphanumeric(alphanumericLength),
+		"AlphanumericString": response.AlphaNumeric.String,
+		"Unambiguous":        opts.Unambiguous,
+		"Mask":               opts.Mask,
+		"MaskString":         maskString(response.Mask),
 		"Version":            Version,
 		"BuildTime":          BuildTime,
 		"CommitHash":         CommitHash,
@@ -220,16 +681,49 @@ func generateStrings(c *gin.Context) {
 	}
 }
 
-func main() {
-	gin.SetMode(gin.ReleaseMode)
-	r := gin.Default()
-
+// registerRoutes adds the static assets and every endpoint to r
+func registerRoutes(r *gin.Engine) {
 	// Serve static files (CSS, JS)
 	r.Static("/static", "./static")
 
 	// Define the endpoints
 	r.GET("/json", generateStrings) // JSON response
 	r.GET("/", generateStrings)     // HTML response
+	r.GET("/passphrase", generatePassphrase)
+	r.GET("/uuid", generateUUIDs)
+	r.GET("/id", generateIDs)
+	r.GET("/bytes", generateBytes)
+	r.GET("/int", generateInts)
+	r.GET("/roll", rollHandler)
+	r.POST("/shuffle", shuffleHandler)
+	r.POST("/pick", pickHandler)
+	r.GET("/draw", drawHandler)
+	r.GET("/draw/key", drawKeyHandler)
+	r.GET("/pin", pinHandler)
+	r.GET("/regex", regexHandler)
+	r.GET("/totp", totpHandler)
+	r.GET("/apikey", apiKeyHandler)
+	r.GET("/apikey/verify", apiKeyVerifyHandler)
+	r.POST("/apikey/verify", apiKeyVerifyHandler)
+	r.GET("/sshkey", sshKeyHandler)
+	r.GET("/wireguard", wireGuardHandler)
+	r.GET("/jwk", jwkHandler)
+	r.GET("/keypair", keypairHandler)
+	r.GET("/cert", certHandler)
+}
+
+func main() {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.Default()
+
+	registerRoutes(r)
+
+	if _, _, err := drawSigningKey(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if _, err := envPINBlocklist(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 
 	// print out the Version, BuildTime and Commit Hash
 	fmt.Printf("Version: %s\n", Version)
@@ -293,7 +787,7 @@ func (h *universalHandler) tryAPIGatewayV2(ctx context.Context, payload []byte)
 		if err != nil {
 			return nil, err
 		}
-		return json.Marshal(resp)
+		return json.Marshal(sanitizeV2Response(resp))
 	}
 	return nil, fmt.Errorf("not v2")
 }
@@ -311,7 +805,7 @@ func (h *universalHandler) tryLambdaFunctionURL(ctx context.Context, payload []b
 		if err != nil {
 			return nil, err
 		}
-		return json.Marshal(resp)
+		return json.Marshal(sanitizeV2Response(resp))
 	}
 	return nil, fmt.Errorf("not function url")
 }
@@ -379,7 +873,7 @@ func (h *universalHandler) coerceToV2(ctx context.Context, generic map[string]in
 	if err != nil {
 		return nil, err
 	}
-	return json.Marshal(resp)
+	return json.Marshal(sanitizeV2Response(resp))
 }
 
 // coerceToV1 attempts to convert generic payload to API Gateway v1 format
@@ -441,10 +935,39 @@ func sanitizeV1Response(resp events.APIGatewayProxyResponse) events.APIGatewayPr
 	if resp.MultiValueHeaders == nil {
 		resp.MultiValueHeaders = map[string][]string{}
 	}
-	resp.IsBase64Encoded = false
+	contentType := ""
+	if ctype := resp.MultiValueHeaders["Content-Type"]; len(ctype) > 0 {
+		contentType = ctype[0]
+	}
+	resp.Body, resp.IsBase64Encoded = binarySafeBody(resp.Body, resp.IsBase64Encoded, contentType)
+	return resp
+}
+
+// sanitizeV2Response base64 encodes binary v2 response bodies the adapter left as text.
+func sanitizeV2Response(resp events.APIGatewayV2HTTPResponse) events.APIGatewayV2HTTPResponse {
+	resp.Body, resp.IsBase64Encoded = binarySafeBody(resp.Body, resp.IsBase64Encoded, resp.Headers["Content-Type"])
 	return resp
 }
 
+// binarySafeBody base64 encodes body unless it is already encoded or its content type is text.
+// The adapter only encodes bodies that are invalid UTF-8, which misses binary output
+// (raw bytes, images) that happens to be valid UTF-8.
+func binarySafeBody(body string, isBase64 bool, contentType string) (string, bool) {
+	if isBase64 || contentType == "" || isTextContentType(contentType) {
+		return body, isBase64
+	}
+	return base64.StdEncoding.EncodeToString([]byte(body)), true
+}
+
+// isTextContentType reports whether a response with this content type can be returned as a plain string
+func isTextContentType(contentType string) bool {
+	ct := strings.ToLower(contentType)
+	return strings.HasPrefix(ct, "text/") ||
+		strings.Contains(ct, "json") ||
+		strings.Contains(ct, "xml") ||
+		strings.Contains(ct, "javascript")
+}
+
 // convertFunctionURLToV2 maps a Lambda Function URL event to an APIGateway v2 HTTP request for the adapter.
 func convertFunctionURLToV2(f events.LambdaFunctionURLRequest) events.APIGatewayV2HTTPRequest {
 	return events.APIGatewayV2HTTPRequest{
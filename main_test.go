@@ -2,11 +2,15 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"io"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/aws/aws-lambda-go/events"
 	ginadapter "github.com/awslabs/aws-lambda-go-api-proxy/gin"
@@ -152,18 +156,63 @@ func TestAPIGatewayV2Event(t *testing.T) {
 	assert.Equal(t, 200, resp.StatusCode, "Should return 200 status code")
 }
 
+// TestLambdaBinaryResponse verifies binary bodies are base64 encoded for API Gateway
+func TestLambdaBinaryResponse(t *testing.T) {
+	r := setupRouter()
+	handler := &universalHandler{v1: ginadapter.New(r), v2: ginadapter.NewV2(r)}
+
+	v1Event := events.APIGatewayProxyRequest{
+		HTTPMethod:            "GET",
+		Path:                  "/json",
+		QueryStringParameters: map[string]string{"format": "qr.png"},
+	}
+	payload, err := json.Marshal(v1Event)
+	assert.NoError(t, err)
+	result, err := handler.tryAPIGatewayV1(context.Background(), payload)
+	assert.NoError(t, err)
+
+	var resp events.APIGatewayProxyResponse
+	assert.NoError(t, json.Unmarshal(result, &resp))
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.True(t, resp.IsBase64Encoded, "PNG body should be base64 encoded")
+	decoded, err := base64.StdEncoding.DecodeString(resp.Body)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(decoded), "\x89PNG"), "Decoded body should be a PNG")
+
+	// Raw bytes that happen to be valid UTF-8 must still be encoded
+	v2 := sanitizeV2Response(events.APIGatewayV2HTTPResponse{
+		Headers: map[string]string{"Content-Type": "application/octet-stream"},
+		Body:    "abc",
+	})
+	assert.True(t, v2.IsBase64Encoded)
+	assert.Equal(t, "YWJj", v2.Body)
+
+	v1 := sanitizeV1Response(events.APIGatewayProxyResponse{
+		MultiValueHeaders: map[string][]string{"Content-Type": {"application/json; charset=utf-8"}},
+		Body:              "{}",
+	})
+	assert.False(t, v1.IsBase64Encoded, "JSON should stay plain text")
+	assert.Equal(t, "{}", v1.Body)
+}
+
 // --- User-Agent behavior tests (previously in main_useragent_test.go) ---
 
 // helper to create a test router with the relevant routes
 func setupRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
-	r.Static("/static", "./static")
-	r.GET("/json", generateStrings)
-	r.GET("/", generateStrings)
+	registerRoutes(r)
 	return r
 }
 
+// serveRequest sends method target, with an optional body, through r and returns the recorded response
+func serveRequest(r *gin.Engine, method, target string, body io.Reader) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, body)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
 func TestUserAgent_CLI_ReturnsJSON(t *testing.T) {
 	r := setupRouter()
 
@@ -213,3 +262,138 @@ func TestUserAgent_Browser_ReturnsHTML(t *testing.T) {
 		t.Fatalf("expected HTML body for browser UA, got %s", body)
 	}
 }
+
+func TestParseCharset(t *testing.T) {
+	charset, err := ParseCharset("aabbcc")
+	assert.NoError(t, err)
+	assert.Equal(t, []rune("abc"), charset, "Duplicates should be removed in order")
+
+	charset, err = ParseCharset("αβγα")
+	assert.NoError(t, err)
+	assert.Equal(t, []rune("αβγ"), charset, "Multi-byte runes should be handled")
+
+	_, err = ParseCharset("")
+	assert.Error(t, err, "Empty charset should be rejected")
+
+	_, err = ParseCharset("xxxx")
+	assert.Error(t, err, "Single distinct character should be rejected")
+
+	_, err = ParseCharset("ab c")
+	assert.Error(t, err, "Whitespace should be rejected")
+}
+
+func TestGenerateStringsCharset(t *testing.T) {
+	r := setupRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/json?a=40&charset=%CE%B1%CE%B2x", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 40, utf8.RuneCountInString(response.AlphaNumeric.String))
+	for _, r := range response.AlphaNumeric.String {
+		assert.Contains(t, "αβx", string(r), "Output should only use the supplied charset")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/json?charset=z", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "One-character charset should be rejected")
+	assert.Contains(t, w.Body.String(), "at least 2 distinct characters")
+}
+
+func TestCharsetPresets(t *testing.T) {
+	for name, preset := range charsetPresets {
+		charset, err := ParseCharset(preset.Alphabet)
+		assert.NoError(t, err, "Preset %s should be a valid charset", name)
+		assert.Equal(t, len([]rune(preset.Alphabet)), len(charset), "Preset %s should not contain duplicates", name)
+		assert.InDelta(t, math.Log2(float64(len(charset))), preset.BitsPerChar, 0.001, "Preset %s entropy should match its alphabet", name)
+	}
+
+	_, err := LookupPreset("nope")
+	assert.Error(t, err, "Unknown preset should be rejected")
+}
+
+func TestGenerateStringsPreset(t *testing.T) {
+	r := setupRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/json?a=32&preset=base58", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "base58", response.AlphaNumeric.Preset)
+	assert.Equal(t, 187.46, response.AlphaNumeric.Entropy)
+	for _, r := range response.AlphaNumeric.String {
+		assert.Contains(t, charsetPresets["base58"].Alphabet, string(r))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/json?preset=hex&charset=ab", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "preset and charset should be mutually exclusive")
+}
+
+func TestUnambiguousOption(t *testing.T) {
+	opts := StringOptions{Unambiguous: true}
+	for i := 0; i < 20; i++ {
+		for _, r := range GenerateRandomAlphanumericWithOptions(99, opts) {
+			assert.False(t, strings.ContainsRune(AmbiguousChars, r), "Alphanumeric string should not contain %q", r)
+		}
+		for _, r := range GenerateRandomPrintableWithOptions(99, opts) {
+			assert.False(t, strings.ContainsRune(AmbiguousChars, r), "Printable string should not contain %q", r)
+		}
+	}
+
+	r := setupRouter()
+	req := httptest.NewRequest(http.MethodGet, "/json?charset=0O1&unambiguous=1", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Charset left with fewer than 2 characters should be rejected")
+
+	req = httptest.NewRequest(http.MethodGet, "/?unambiguous=1", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `id="unambiguous"`, "HTML page should render the toggle")
+	assert.Contains(t, w.Body.String(), "checked", "Toggle should reflect the query parameter")
+}
+
+func TestGenerateStringsSymbols(t *testing.T) {
+	r := setupRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/json?p=20&symbols=%21%40%23&symcount=2", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	if assert.NotNil(t, response.Printable.Symbols) {
+		assert.Equal(t, 2, *response.Printable.Symbols, "Response should report the inserted symbol count")
+	}
+	found := 0
+	for _, r := range response.Printable.String {
+		if strings.ContainsRune("!@#", r) {
+			found++
+		}
+	}
+	assert.Equal(t, 2, found, "Printable string should contain exactly 2 symbols from the pool")
+
+	req = httptest.NewRequest(http.MethodGet, "/json?symcount=0", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Contains(t, w.Body.String(), `"symbols": 0`, "A symbol count of zero should still be reported")
+
+	for _, query := range []string{"symcount=2&symmin=1", "symmin=5&symmax=2", "symbols=ab", "symcount=1&minupper=1", "p=4&symcount=5", "p=4&symmin=5"} {
+		req = httptest.NewRequest(http.MethodGet, "/json?"+query, nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Query %q should be rejected", query)
+	}
+}
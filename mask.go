@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaskPlaceholders maps each mask placeholder to the characters it is replaced with.
+// Any other character is copied literally; a backslash makes the next character literal.
+var MaskPlaceholders = map[rune]string{
+	'A': "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	'a': "abcdefghijklmnopqrstuvwxyz",
+	'9': "0123456789",
+	'?': DefaultAlphanumericCharset,
+	'#': "0123456789ABCDEF",
+	'*': DefaultSymbols,
+}
+
+// parseMaskOption reads and validates the mask query parameter
+func parseMaskOption(c *gin.Context, opts *StringOptions) error {
+	mask, ok := c.GetQuery("mask")
+	if !ok || mask == "" {
+		return nil
+	}
+	if err := ValidateMask(mask); err != nil {
+		return err
+	}
+	opts.Mask = mask
+	return nil
+}
+
+// ValidateMask checks that mask is printable, fits MaxAllowedLength, has at least one
+// placeholder and does not end with a dangling backslash.
+func ValidateMask(mask string) error {
+	if !utf8.ValidString(mask) {
+		return fmt.Errorf("mask is not valid UTF-8")
+	}
+	if utf8.RuneCountInString(mask) > MaxAllowedLength {
+		return fmt.Errorf("mask must be at most %d characters", MaxAllowedLength)
+	}
+	placeholders := 0
+	escaped := false
+	for _, r := range mask {
+		if !unicode.IsPrint(r) {
+			return fmt.Errorf("mask contains non-printable character %U", r)
+		}
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case MaskPlaceholders[r] != "":
+			placeholders++
+		}
+	}
+	if escaped {
+		return fmt.Errorf("mask ends with an unfinished escape")
+	}
+	if placeholders == 0 {
+		return fmt.Errorf("mask must contain at least one placeholder (A, a, 9, ?, #, *)")
+	}
+	return nil
+}
+
+// GenerateFromMask replaces every placeholder of a validated mask with a random
+// character of its class and returns the result with its entropy in bits.
+func GenerateFromMask(mask string, unambiguous bool) (string, float64) {
+	var b strings.Builder
+	entropy := 0.0
+	escaped := false
+	for _, r := range mask {
+		if escaped {
+			b.WriteRune(r)
+			escaped = false
+			continue
+		}
+		if r == '\\' {
+			escaped = true
+			continue
+		}
+		class := MaskPlaceholders[r]
+		if class == "" {
+			b.WriteRune(r)
+			continue
+		}
+		chars := []rune(class)
+		if unambiguous {
+			chars = RemoveAmbiguous(chars)
+		}
+		b.WriteRune(chars[cryptoRandInt(len(chars))])
+		entropy += math.Log2(float64(len(chars)))
+	}
+	return b.String(), math.Round(entropy*100) / 100
+}
+
+// maskString returns the generated mask string for the HTML page, or "" when no mask was requested
+func maskString(mask *RandomString) string {
+	if mask == nil {
+		return ""
+	}
+	return mask.String
+}
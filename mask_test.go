@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateFromMask(t *testing.T) {
+	s, entropy := GenerateFromMask(`AAAA-9999-aaaa-????-\A\9`, false)
+	assert.Regexp(t, regexp.MustCompile(`^[A-Z]{4}-[0-9]{4}-[a-z]{4}-[A-Za-z0-9]{4}-A9$`), s)
+	assert.InDelta(t, 8*4.7004+4*3.3219+4*5.9542, entropy, 0.05)
+
+	s, _ = GenerateFromMask("9999999999", true)
+	assert.Regexp(t, regexp.MustCompile(`^[2346789]{10}$`), s, "Unambiguous mode should drop 0, 1 and 5")
+
+	for _, mask := range []string{"----", `AA\`, "A\tA"} {
+		assert.Error(t, ValidateMask(mask), "Mask %q should be rejected", mask)
+	}
+	assert.NoError(t, ValidateMask("#### ****"))
+}
+
+func TestGenerateStringsMask(t *testing.T) {
+	r := setupRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/json?mask="+url.QueryEscape("VOUCHER-AAAA-9999"), nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	if assert.NotNil(t, response.Mask) {
+		assert.Regexp(t, regexp.MustCompile(`^VOUCHER-[A-Z]{4}-[0-9]{4}$`), response.Mask.String)
+		assert.Equal(t, 17, response.Mask.Length)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/?mask=9999", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Regexp(t, regexp.MustCompile(`id="mask-string">[0-9]{4}<`), w.Body.String(), "HTML page should render the mask string")
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// effLargeWordlist is the EFF large wordlist (7776 words, one "ddddd<TAB>word" entry per line).
+// https://www.eff.org/deeplinks/2016/07/new-wordlists-random-passphrases
+//
+//go:embed wordlists/eff_large_wordlist.txt
+var effLargeWordlist string
+
+// effWords holds the words of the EFF large wordlist in dice-roll order
+var effWords = loadWordlist(effLargeWordlist)
+
+const (
+	DefaultPassphraseWords = 6
+	MaxPassphraseWords     = 20
+	maxSeparatorLength     = 8
+	// DefaultPassphraseSeparator never occurs in the wordlist, unlike "-" which appears
+	// in drop-down, felt-tip, t-shirt and yo-yo, so passphrases split back into words.
+	DefaultPassphraseSeparator = "."
+)
+
+// Capitalization styles accepted by the case query parameter
+const (
+	CaseLower  = "lower"
+	CaseUpper  = "upper"
+	CaseTitle  = "title"
+	CaseRandom = "random" // each word is independently lower or title case
+)
+
+// PassphraseOptions controls how a diceware passphrase is built
+type PassphraseOptions struct {
+	Words     int
+	Separator string
+	Case      string
+	Digit     bool // insert one random digit at a random position
+	Symbol    bool // insert one random symbol at a random position
+}
+
+// Passphrase is the generated passphrase together with its estimated entropy
+type Passphrase struct {
+	Words   int     `json:"words"`
+	String  string  `json:"string"`
+	Entropy float64 `json:"entropy_bits"`
+}
+
+// loadWordlist parses a diceware list of "rolls<TAB>word" lines
+func loadWordlist(list string) []string {
+	lines := strings.Split(strings.TrimSpace(list), "\n")
+	words := make([]string, 0, len(lines))
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			log.Fatalf("malformed wordlist line %q", line)
+		}
+		words = append(words, fields[1])
+	}
+	return words
+}
+
+// passphraseParams lists the query parameters read by parsePassphraseOptions
+var passphraseParams = []string{"words", "sep", "case", "digit", "symbol"}
+
+// parsePassphraseOptions extracts passphrase settings from the words, sep, case, digit and symbol query parameters
+func parsePassphraseOptions(c *gin.Context) (PassphraseOptions, error) {
+	opts := PassphraseOptions{Words: DefaultPassphraseWords, Separator: DefaultPassphraseSeparator, Case: CaseLower}
+
+	words, ok, err := queryInt(c, "words", 1, MaxPassphraseWords)
+	if err != nil {
+		return opts, err
+	}
+	if ok {
+		opts.Words = words
+	}
+	if sep, ok := c.GetQuery("sep"); ok {
+		if utf8.RuneCountInString(sep) > maxSeparatorLength {
+			return opts, fmt.Errorf("sep must be at most %d characters", maxSeparatorLength)
+		}
+		for _, r := range sep {
+			if !unicode.IsPrint(r) {
+				return opts, fmt.Errorf("sep contains non-printable character %U", r)
+			}
+		}
+		opts.Separator = sep
+	}
+	if style, ok := c.GetQuery("case"); ok {
+		switch style = strings.ToLower(style); style {
+		case CaseLower, CaseUpper, CaseTitle, CaseRandom:
+			opts.Case = style
+		default:
+			return opts, fmt.Errorf("case must be one of lower, upper, title, random, got %q", style)
+		}
+	}
+	if opts.Digit, err = queryBool(c, "digit"); err != nil {
+		return opts, err
+	}
+	if opts.Symbol, err = queryBool(c, "symbol"); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// GeneratePassphrase picks opts.Words words from the EFF large wordlist using cryptoRandInt.
+// The reported entropy is conservative: it counts word choice, random capitalization
+// and the inserted digit/symbol values, but not their positions.
+func GeneratePassphrase(opts PassphraseOptions) Passphrase {
+	if opts.Words <= 0 {
+		opts.Words = DefaultPassphraseWords
+	}
+	if opts.Words > MaxPassphraseWords {
+		opts.Words = MaxPassphraseWords
+	}
+
+	entropy := float64(opts.Words) * math.Log2(float64(len(effWords)))
+	words := make([]string, opts.Words)
+	for i := range words {
+		word := effWords[cryptoRandInt(len(effWords))]
+		switch opts.Case {
+		case CaseUpper:
+			word = strings.ToUpper(word)
+		case CaseTitle:
+			word = titleCase(word)
+		case CaseRandom:
+			if cryptoRandInt(2) == 1 {
+				word = titleCase(word)
+			}
+			entropy++
+		}
+		words[i] = word
+	}
+
+	runes := []rune(strings.Join(words, opts.Separator))
+	if opts.Digit {
+		runes = insertRune(runes, rune('0'+cryptoRandInt(10)))
+		entropy += math.Log2(10)
+	}
+	if opts.Symbol {
+		symbols := []rune(DefaultSymbols)
+		runes = insertRune(runes, symbols[cryptoRandInt(len(symbols))])
+		entropy += math.Log2(float64(len(symbols)))
+	}
+
+	return Passphrase{
+		Words:   opts.Words,
+		String:  string(runes),
+		Entropy: math.Round(entropy*100) / 100,
+	}
+}
+
+// titleCase upper-cases the first letter of an ASCII word
+func titleCase(word string) string {
+	if word == "" {
+		return word
+	}
+	return strings.ToUpper(word[:1]) + word[1:]
+}
+
+// insertRune inserts r at a uniformly random position of s (including both ends)
+func insertRune(s []rune, r rune) []rune {
+	pos := cryptoRandInt(len(s) + 1)
+	s = append(s, 0)
+	copy(s[pos+1:], s[pos:])
+	s[pos] = r
+	return s
+}
+
+// wantsPassphrase reports whether a /json request asked for a passphrase as well
+func wantsPassphrase(c *gin.Context) (bool, error) {
+	if _, ok := c.GetQuery("words"); ok {
+		return true, nil
+	}
+	return queryBool(c, "passphrase")
+}
+
+// generatePassphrase handles GET /passphrase
+func generatePassphrase(c *gin.Context) {
+	opts, err := parsePassphraseOptions(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store, no-cache, must-revalidate")
+	c.IndentedJSON(http.StatusOK, GeneratePassphrase(opts))
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEFFWordlist(t *testing.T) {
+	assert.Len(t, effWords, 7776, "EFF large wordlist should contain 6^5 words")
+	assert.Equal(t, "abacus", effWords[0])
+	assert.Equal(t, "zoom", effWords[len(effWords)-1])
+}
+
+func TestDefaultPassphraseSeparator(t *testing.T) {
+	for _, word := range effWords {
+		assert.NotContains(t, word, DefaultPassphraseSeparator, "Separator must not occur in %q", word)
+	}
+	r := setupRouter()
+	req := httptest.NewRequest(http.MethodGet, "/passphrase?words=8", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var p Passphrase
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	assert.Len(t, strings.Split(p.String, DefaultPassphraseSeparator), 8, "Default passphrase should split back into its words")
+}
+
+func TestGeneratePassphrase(t *testing.T) {
+	p := GeneratePassphrase(PassphraseOptions{Words: 5, Separator: ".", Case: CaseTitle})
+	words := strings.Split(p.String, ".")
+	assert.Len(t, words, 5)
+	for _, w := range words {
+		assert.Equal(t, titleCase(strings.ToLower(w)), w, "Words should be title case")
+	}
+	assert.InDelta(t, 64.62, p.Entropy, 0.01)
+
+	p = GeneratePassphrase(PassphraseOptions{Words: 3, Separator: " ", Case: CaseLower, Digit: true, Symbol: true})
+	assert.True(t, strings.ContainsAny(p.String, "0123456789"), "Passphrase should contain a digit")
+	assert.True(t, strings.ContainsAny(p.String, DefaultSymbols), "Passphrase should contain a symbol")
+}
+
+func TestPassphraseEndpoint(t *testing.T) {
+	r := setupRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/passphrase?words=4&sep=_&case=upper", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var p Passphrase
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	assert.Equal(t, 4, p.Words)
+	assert.Len(t, strings.Split(p.String, "_"), 4)
+	assert.Equal(t, strings.ToUpper(p.String), p.String)
+
+	req = httptest.NewRequest(http.MethodGet, "/json?words=3", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var response Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.NotNil(t, response.Passphrase, "/json should include a passphrase on request")
+
+	req = httptest.NewRequest(http.MethodGet, "/passphrase?case=wavy", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultPINLength = 6
+	MinPINLength     = 4
+	MaxPINLength     = 12
+	maxPINBlocklist  = 1000
+	maxPINAttempts   = 10000
+)
+
+// commonPINs lists frequently chosen PINs that are not already caught by the
+// sequence, repeat and palindrome rules. Extend it with PIN_BLOCKLIST or block=.
+var commonPINs = []string{
+	"1212", "1004", "2000", "6969", "1122", "1313", "2001", "1010", "1999", "2580",
+	"0852", "1984", "1986", "1987", "1988", "1989", "1990", "2468", "1357", "0007",
+	"123123", "121212", "112233", "789456", "159753", "147258", "258369", "696969",
+	"101010", "131313", "123321", "000007", "102030", "142536", "753951", "520520",
+}
+
+// PINResponse is the JSON payload returned by /pin
+type PINResponse struct {
+	Length  int      `json:"length"`
+	PINs    []string `json:"pins"`
+	Entropy float64  `json:"entropy_bits"`
+}
+
+// PINGenerator draws PINs uniformly from every PIN of a given length that is not a
+// sequence, a repeat, a palindrome or on the blocklist.
+type PINGenerator struct {
+	Length    int
+	Blocklist map[string]struct{}
+}
+
+// pinEnvBlocklist holds the PIN_BLOCKLIST entries, parsed once per process
+var pinEnvBlocklist struct {
+	once    sync.Once
+	entries []string
+	err     error
+}
+
+// envPINBlocklist returns the validated PIN_BLOCKLIST entries.
+// main calls it at startup so a malformed PIN_BLOCKLIST fails before serving.
+func envPINBlocklist() ([]string, error) {
+	pinEnvBlocklist.once.Do(func() {
+		if env := os.Getenv("PIN_BLOCKLIST"); env != "" {
+			pinEnvBlocklist.entries, pinEnvBlocklist.err = parsePINList("PIN_BLOCKLIST", strings.Split(env, ","))
+		}
+	})
+	return pinEnvBlocklist.entries, pinEnvBlocklist.err
+}
+
+// parsePINList trims the entries of a blocklist, drops empty ones and checks the rest are numeric
+func parsePINList(source string, entries []string) ([]string, error) {
+	pins := make([]string, 0, len(entries))
+	for _, pin := range entries {
+		pin = strings.TrimSpace(pin)
+		if pin == "" {
+			continue
+		}
+		if strings.Trim(pin, "0123456789") != "" {
+			return nil, fmt.Errorf("%s entry %q is not numeric", source, pin)
+		}
+		pins = append(pins, pin)
+	}
+	return pins, nil
+}
+
+// NewPINGenerator builds a generator with the built-in blocklist, PIN_BLOCKLIST and extra entries
+func NewPINGenerator(length int, extra []string) (*PINGenerator, error) {
+	if length < MinPINLength || length > MaxPINLength {
+		return nil, fmt.Errorf("len must be between %d and %d", MinPINLength, MaxPINLength)
+	}
+	env, err := envPINBlocklist()
+	if err != nil {
+		return nil, err
+	}
+	extra, err = parsePINList("block", extra)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &PINGenerator{Length: length, Blocklist: map[string]struct{}{}}
+	for _, list := range [][]string{commonPINs, env, extra} {
+		for _, pin := range list {
+			g.Blocklist[pin] = struct{}{}
+		}
+	}
+	return g, nil
+}
+
+// Allowed reports whether pin passes every rule
+func (g *PINGenerator) Allowed(pin string) bool {
+	if _, blocked := g.Blocklist[pin]; blocked {
+		return false
+	}
+	return !isPINSequence(pin) && !isPalindrome(pin)
+}
+
+// Generate returns a PIN drawn uniformly from the allowed space by rejection sampling
+func (g *PINGenerator) Generate() (string, error) {
+	digits := []rune("0123456789")
+	for i := 0; i < maxPINAttempts; i++ {
+		pin := GenerateRandomFromCharset(g.Length, digits)
+		if g.Allowed(pin) {
+			return pin, nil
+		}
+	}
+	return "", fmt.Errorf("blocklist leaves no usable %d-digit PINs", g.Length)
+}
+
+// Entropy returns log2 of the number of allowed PINs
+func (g *PINGenerator) Entropy() float64 {
+	total := math.Pow(10, float64(g.Length))
+	// Palindromes (which include every repeat) are fixed by their first half
+	rejected := math.Pow(10, float64((g.Length+1)/2))
+	// Ascending and descending runs of length <= 10 start at 11-length digits each
+	if g.Length <= 10 {
+		rejected += 2 * float64(11-g.Length)
+	}
+	for pin := range g.Blocklist {
+		if len(pin) == g.Length && !isPINSequence(pin) && !isPalindrome(pin) {
+			rejected++
+		}
+	}
+	return math.Round(math.Log2(total-rejected)*100) / 100
+}
+
+// isPINSequence reports whether every digit is one more, or every digit one less, than the previous
+func isPINSequence(pin string) bool {
+	up, down := true, true
+	for i := 1; i < len(pin); i++ {
+		step := int(pin[i]) - int(pin[i-1])
+		up = up && step == 1
+		down = down && step == -1
+	}
+	return up || down
+}
+
+// isPalindrome reports whether pin reads the same backwards; this includes repeats such as 1111
+func isPalindrome(pin string) bool {
+	for i, j := 0, len(pin)-1; i < j; i, j = i+1, j-1 {
+		if pin[i] != pin[j] {
+			return false
+		}
+	}
+	return true
+}
+
+// pinHandler handles GET /pin?len=6&count=N&block=1234,5678
+func pinHandler(c *gin.Context) {
+	length, ok, err := queryInt(c, "len", MinPINLength, MaxPINLength)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !ok {
+		length = DefaultPINLength
+	}
+	count, ok, err := queryInt(c, "count", 1, MaxBatchCount)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !ok {
+		count = 1
+	}
+	var extra []string
+	if val := c.Query("block"); val != "" {
+		if extra = strings.Split(val, ","); len(extra) > maxPINBlocklist {
+			respondError(c, fmt.Errorf("block may list at most %d PINs", maxPINBlocklist))
+			return
+		}
+	}
+
+	if _, err := envPINBlocklist(); err != nil {
+		// A bad PIN_BLOCKLIST is a server misconfiguration, not a client error
+		c.Header("Cache-Control", "no-store, no-cache, must-revalidate")
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	g, err := NewPINGenerator(length, extra)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	pins := make([]string, count)
+	for i := range pins {
+		if pins[i], err = g.Generate(); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+	respondList(c, pins, PINResponse{Length: length, PINs: pins, Entropy: g.Entropy()})
+}
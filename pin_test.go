@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPINRules(t *testing.T) {
+	g, err := NewPINGenerator(4, []string{"4826"})
+	assert.NoError(t, err)
+	for _, pin := range []string{"1234", "9876", "1111", "1221", "1212", "4826"} {
+		assert.False(t, g.Allowed(pin), "PIN %s should be rejected", pin)
+	}
+	for _, pin := range []string{"4827", "1235", "9021"} {
+		assert.True(t, g.Allowed(pin), "PIN %s should be allowed", pin)
+	}
+
+	for i := 0; i < 200; i++ {
+		pin, err := g.Generate()
+		assert.NoError(t, err)
+		assert.Len(t, pin, 4)
+		assert.True(t, g.Allowed(pin))
+	}
+
+	_, err = NewPINGenerator(3, nil)
+	assert.Error(t, err)
+	_, err = NewPINGenerator(4, []string{"12a4"})
+	assert.EqualError(t, err, `block entry "12a4" is not numeric`)
+}
+
+func TestPINEnvBlocklist(t *testing.T) {
+	reset := func() {
+		pinEnvBlocklist.once = sync.Once{}
+		pinEnvBlocklist.entries, pinEnvBlocklist.err = nil, nil
+	}
+	t.Cleanup(reset)
+	r := setupRouter()
+
+	t.Setenv("PIN_BLOCKLIST", " 4826, ,1357 ")
+	reset()
+	entries, err := envPINBlocklist()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"4826", "1357"}, entries)
+	g, err := NewPINGenerator(4, nil)
+	assert.NoError(t, err)
+	assert.False(t, g.Allowed("4826"), "PIN_BLOCKLIST entries should be blocked")
+
+	t.Setenv("PIN_BLOCKLIST", "1234,12a4")
+	reset()
+	_, err = envPINBlocklist()
+	assert.EqualError(t, err, `PIN_BLOCKLIST entry "12a4" is not numeric`)
+
+	req := httptest.NewRequest(http.MethodGet, "/pin", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code, "A bad PIN_BLOCKLIST is a server error")
+}
+
+func TestPINEntropy(t *testing.T) {
+	g := &PINGenerator{Length: 4, Blocklist: map[string]struct{}{}}
+	allowed := 0
+	for n := 0; n < 10000; n++ {
+		pin := []byte{byte('0' + n/1000), byte('0' + n/100%10), byte('0' + n/10%10), byte('0' + n%10)}
+		if g.Allowed(string(pin)) {
+			allowed++
+		}
+	}
+	assert.Equal(t, 10000-100-14, allowed)
+	assert.InDelta(t, math.Log2(float64(allowed)), g.Entropy(), 0.01, "Entropy should match the enumerated space")
+}
+
+func TestPINEndpoint(t *testing.T) {
+	r := setupRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/pin?len=8&count=3", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp PINResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp.PINs, 3)
+	assert.Greater(t, resp.Entropy, 26.0)
+
+	for _, query := range []string{"len=20", "block=12a4"} {
+		req = httptest.NewRequest(http.MethodGet, "/pin?"+query, nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Query %q should be rejected", query)
+	}
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"math/big"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPolicyAttempts bounds the rejection sampling used to enforce MaxRepeat
+const maxPolicyAttempts = 1000
+
+// Policy describes the composition a generated password must satisfy.
+// A zero minimum means the class is allowed but not required; MaxRepeat limits
+// how many identical characters may appear in a row (0 means unlimited).
+type Policy struct {
+	MinUpper  int    `json:"min_upper"`
+	MinLower  int    `json:"min_lower"`
+	MinDigit  int    `json:"min_digit"`
+	MinSymbol int    `json:"min_symbol"`
+	MaxRepeat int    `json:"max_repeat,omitempty"`
+	Symbols   string `json:"symbols"`
+}
+
+// policyClass is one character class of a policy together with its required minimum
+type policyClass struct {
+	chars []rune
+	min   int
+}
+
+// parsePolicy builds a Policy from the minupper, minlower, mindigit, minsymbol
+// and maxrepeat query parameters. It returns nil when none are present.
+func parsePolicy(c *gin.Context) (*Policy, error) {
+	p := Policy{Symbols: DefaultSymbols}
+	found := false
+	ints := []struct {
+		key string
+		dst *int
+	}{
+		{"minupper", &p.MinUpper},
+		{"minlower", &p.MinLower},
+		{"mindigit", &p.MinDigit},
+		{"minsymbol", &p.MinSymbol},
+		{"maxrepeat", &p.MaxRepeat},
+	}
+	for _, q := range ints {
+		n, ok, err := queryInt(c, q.key, 0, MaxAllowedLength)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			*q.dst = n
+			found = true
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+// parsePolicyOption sets opts.Policy from the query string and applies the symbol pool to it
+func parsePolicyOption(c *gin.Context, opts *StringOptions) error {
+	policy, err := parsePolicy(c)
+	if err != nil || policy == nil {
+		return err
+	}
+	if opts.SymCountSet {
+		return fmt.Errorf("symcount, symmin and symmax cannot be combined with a password policy; use minsymbol")
+	}
+	if opts.Symbols != nil {
+		policy.Symbols = string(opts.Symbols)
+	}
+	opts.Policy = policy
+	return nil
+}
+
+// ParseSymbols validates a caller supplied symbol pool: every rune must be a
+// printable, non-alphanumeric, non-space character. Duplicates are removed.
+func ParseSymbols(s string) ([]rune, error) {
+	seen := make(map[rune]struct{}, len(s))
+	symbols := make([]rune, 0, len(s))
+	for _, r := range s {
+		if !unicode.IsPrint(r) || unicode.IsSpace(r) || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return nil, fmt.Errorf("symbols must be printable non-alphanumeric characters, got %q", r)
+		}
+		if _, dup := seen[r]; dup {
+			continue
+		}
+		seen[r] = struct{}{}
+		symbols = append(symbols, r)
+	}
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("symbols must not be empty")
+	}
+	return symbols, nil
+}
+
+// classes returns the upper, lower, digit and symbol classes of the policy
+func (p Policy) classes(unambiguous bool) []policyClass {
+	symbols := p.Symbols
+	if symbols == "" {
+		symbols = DefaultSymbols
+	}
+	classes := []policyClass{
+		{chars: []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ"), min: p.MinUpper},
+		{chars: []rune("abcdefghijklmnopqrstuvwxyz"), min: p.MinLower},
+		{chars: []rune("0123456789"), min: p.MinDigit},
+		{chars: []rune(symbols), min: p.MinSymbol},
+	}
+	if unambiguous {
+		for i := range classes {
+			classes[i].chars = RemoveAmbiguous(classes[i].chars)
+		}
+	}
+	return classes
+}
+
+// Validate reports whether a string of the given length can satisfy the policy
+func (p Policy) Validate(length int) error {
+	if p.MinUpper < 0 || p.MinLower < 0 || p.MinDigit < 0 || p.MinSymbol < 0 || p.MaxRepeat < 0 {
+		return fmt.Errorf("policy values must not be negative")
+	}
+	if total := p.MinUpper + p.MinLower + p.MinDigit + p.MinSymbol; total > length {
+		return fmt.Errorf("policy requires at least %d characters but length is %d", total, length)
+	}
+	return nil
+}
+
+// Satisfied reports whether s meets every requirement of the policy
+func (p Policy) Satisfied(s string, unambiguous bool) bool {
+	classes := p.classes(unambiguous)
+	counts := make([]int, len(classes))
+	var prev rune
+	run := 0
+	for _, r := range s {
+		if r == prev {
+			run++
+		} else {
+			prev, run = r, 1
+		}
+		if p.MaxRepeat > 0 && run > p.MaxRepeat {
+			return false
+		}
+		matched := false
+		for i, class := range classes {
+			if containsRune(class.chars, r) {
+				counts[i]++
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for i, class := range classes {
+		if counts[i] < class.min {
+			return false
+		}
+	}
+	return true
+}
+
+// Generate returns a string drawn uniformly from all strings of the given length
+// over the policy alphabet that satisfy the policy. Class counts are sampled
+// with weights equal to the number of strings having those counts, the class
+// layout is shuffled, and each position is filled uniformly from its class;
+// MaxRepeat is then enforced by rejection, which keeps the result uniform.
+func (p Policy) Generate(length int, unambiguous bool) (string, error) {
+	if length > MaxAllowedLength {
+		length = MaxAllowedLength
+	}
+	if err := p.Validate(length); err != nil {
+		return "", err
+	}
+	classes := p.classes(unambiguous)
+	for _, class := range classes {
+		if class.min > 0 && len(class.chars) == 0 {
+			return "", fmt.Errorf("policy requires a character class that has no characters")
+		}
+	}
+
+	binom := binomials(length)
+	table := policyCountTable(classes, binom, length)
+	for attempt := 0; attempt < maxPolicyAttempts; attempt++ {
+		s := samplePolicyString(classes, binom, table, length)
+		if p.MaxRepeat == 0 || maxRun(s) <= p.MaxRepeat {
+			return string(s), nil
+		}
+	}
+	return "", fmt.Errorf("could not satisfy max repeat of %d; relax the policy", p.MaxRepeat)
+}
+
+// policyCountTable returns table[k][m], the number of strings of length m built
+// from classes[k:] in which every class appears at least its minimum number of times.
+func policyCountTable(classes []policyClass, binom [][]*big.Int, length int) [][]*big.Int {
+	table := make([][]*big.Int, len(classes)+1)
+	for k := range table {
+		table[k] = make([]*big.Int, length+1)
+		for m := range table[k] {
+			table[k][m] = new(big.Int)
+		}
+	}
+	table[len(classes)][0].SetInt64(1)
+	for k := len(classes) - 1; k >= 0; k-- {
+		size := big.NewInt(int64(len(classes[k].chars)))
+		for m := 0; m <= length; m++ {
+			for j := classes[k].min; j <= m; j++ {
+				w := classWeight(binom, size, m, j, table[k+1][m-j])
+				table[k][m].Add(table[k][m], w)
+			}
+		}
+	}
+	return table
+}
+
+// classWeight is the number of strings of length m that use exactly j characters
+// of a class with size members and fill the rest with rest strings.
+func classWeight(binom [][]*big.Int, size *big.Int, m, j int, rest *big.Int) *big.Int {
+	w := new(big.Int).Exp(size, big.NewInt(int64(j)), nil)
+	w.Mul(w, binom[m][j])
+	return w.Mul(w, rest)
+}
+
+// samplePolicyString draws one string uniformly from the strings counted by table[0][length]
+func samplePolicyString(classes []policyClass, binom [][]*big.Int, table [][]*big.Int, length int) []rune {
+	layout := make([]int, 0, length)
+	remaining := length
+	for k, class := range classes {
+		size := big.NewInt(int64(len(class.chars)))
+		pick := cryptoRandBig(table[k][remaining])
+		count := class.min
+		for j := class.min; j <= remaining; j++ {
+			w := classWeight(binom, size, remaining, j, table[k+1][remaining-j])
+			if pick.Cmp(w) < 0 {
+				count = j
+				break
+			}
+			pick.Sub(pick, w)
+		}
+		for i := 0; i < count; i++ {
+			layout = append(layout, k)
+		}
+		remaining -= count
+	}
+
+	// Fisher-Yates shuffle of the class layout, then fill each slot from its class.
+	for i := len(layout) - 1; i > 0; i-- {
+		j := cryptoRandInt(i + 1)
+		layout[i], layout[j] = layout[j], layout[i]
+	}
+	result := make([]rune, len(layout))
+	for i, k := range layout {
+		chars := classes[k].chars
+		result[i] = chars[cryptoRandInt(len(chars))]
+	}
+	return result
+}
+
+// binomials returns Pascal's triangle up to n as big integers
+func binomials(n int) [][]*big.Int {
+	binom := make([][]*big.Int, n+1)
+	for i := range binom {
+		binom[i] = make([]*big.Int, i+1)
+		binom[i][0], binom[i][i] = big.NewInt(1), big.NewInt(1)
+		for j := 1; j < i; j++ {
+			binom[i][j] = new(big.Int).Add(binom[i-1][j-1], binom[i-1][j])
+		}
+	}
+	return binom
+}
+
+// cryptoRandBig generates a cryptographically secure random integer in the range [0, max)
+func cryptoRandBig(max *big.Int) *big.Int {
+	if max.Sign() <= 0 {
+		return new(big.Int)
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		log.Fatalf("crypto/rand failed: %v", err)
+	}
+	return n
+}
+
+// maxRun returns the length of the longest run of identical runes in s
+func maxRun(s []rune) int {
+	longest, run := 0, 0
+	for i, r := range s {
+		if i > 0 && r == s[i-1] {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+	return longest
+}
+
+// containsRune reports whether r is present in chars
+func containsRune(chars []rune, r rune) bool {
+	for _, c := range chars {
+		if c == r {
+			return true
+		}
+	}
+	return false
+}
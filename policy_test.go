@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyGenerate(t *testing.T) {
+	p := Policy{MinUpper: 2, MinLower: 2, MinDigit: 2, MinSymbol: 2, MaxRepeat: 1, Symbols: "!@#"}
+	for i := 0; i < 50; i++ {
+		s, err := p.Generate(8, false)
+		assert.NoError(t, err)
+		assert.Equal(t, 8, len(s))
+		assert.True(t, p.Satisfied(s, false), "Generated string %q should satisfy the policy", s)
+	}
+
+	_, err := Policy{MinUpper: 5, MinDigit: 5}.Generate(8, false)
+	assert.Error(t, err, "Minimums longer than the string should be rejected")
+}
+
+func TestPolicyCountTable(t *testing.T) {
+	// Two classes {A,B} and {1}, length 2, at least one of each: "A1","1A","B1","1B"
+	classes := []policyClass{{chars: []rune("AB"), min: 1}, {chars: []rune("1"), min: 1}}
+	table := policyCountTable(classes, binomials(2), 2)
+	assert.Equal(t, int64(4), table[0][2].Int64())
+}
+
+func TestPolicyUniformity(t *testing.T) {
+	// Length 2 over {a} and {1} with at least one digit: "a1", "1a", "11" must be equally likely
+	p := Policy{MinDigit: 1}
+	classes := []policyClass{{chars: []rune("a")}, {chars: []rune("1"), min: p.MinDigit}}
+	binom := binomials(2)
+	table := policyCountTable(classes, binom, 2)
+	counts := map[string]int{}
+	for i := 0; i < 3000; i++ {
+		counts[string(samplePolicyString(classes, binom, table, 2))]++
+	}
+	assert.Len(t, counts, 3)
+	for s, n := range counts {
+		assert.InDelta(t, 1000, n, 150, "Outcome %q should be uniformly likely", s)
+	}
+}
+
+func TestGenerateStringsPolicy(t *testing.T) {
+	r := setupRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/json?p=12&minupper=3&mindigit=3&minsymbol=2&symbols=%21%40", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.NotNil(t, response.Printable.Policy)
+	assert.True(t, response.Printable.Policy.Satisfied(response.Printable.String, false))
+
+	req = httptest.NewRequest(http.MethodGet, "/json?p=4&minupper=5", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Unsatisfiable policy should be rejected")
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// pronounceableConsonants omits letters that are easily misheard or spelled out (c, q, w, x, y)
+	pronounceableConsonants = "bdfghjklmnprstvz"
+	pronounceableVowels     = "aeiou"
+)
+
+// parsePronounceableOptions reads the pronounceable, capitalize and digits query parameters
+func parsePronounceableOptions(c *gin.Context, opts *StringOptions) error {
+	var err error
+	if opts.Pronounceable, err = queryBool(c, "pronounceable"); err != nil {
+		return err
+	}
+	if opts.Capitalize, err = queryBool(c, "capitalize"); err != nil {
+		return err
+	}
+	digits, hasDigits, err := queryInt(c, "digits", 0, MaxAllowedLength)
+	if err != nil {
+		return err
+	}
+	opts.Digits = digits
+
+	if !opts.Pronounceable {
+		if opts.Capitalize || hasDigits {
+			return fmt.Errorf("capitalize and digits require pronounceable=1")
+		}
+		return nil
+	}
+	if opts.Charset != nil {
+		return fmt.Errorf("pronounceable cannot be combined with charset or preset")
+	}
+	return nil
+}
+
+// GeneratePronounceable builds a string of consonant-vowel syllables followed by
+// opts.Digits random digits, so it can be dictated over the phone. It returns the
+// string and its real entropy in bits, which is lower than uniform sampling of the
+// same length because each position only draws from consonants or vowels.
+func GeneratePronounceable(length int, opts StringOptions) (string, float64) {
+	if length <= 0 {
+		return "", 0
+	}
+	if length > MaxAllowedLength {
+		length = MaxAllowedLength
+	}
+	digits := opts.Digits
+	if digits > length {
+		digits = length
+	}
+
+	consonants := []rune(pronounceableConsonants)
+	vowels := []rune(pronounceableVowels)
+	digitSet := []rune("0123456789")
+	if opts.Unambiguous {
+		consonants = RemoveAmbiguous(consonants)
+		vowels = RemoveAmbiguous(vowels)
+		digitSet = RemoveAmbiguous(digitSet)
+	}
+	// The first letter is drawn from upper-case consonants when capitalizing; filter
+	// after upper-casing since s is unambiguous but S is not.
+	leading := consonants
+	if opts.Capitalize {
+		leading = []rune(strings.ToUpper(pronounceableConsonants))
+		if opts.Unambiguous {
+			leading = RemoveAmbiguous(leading)
+		}
+	}
+
+	var b strings.Builder
+	entropy := 0.0
+	for i := 0; i < length-digits; i++ {
+		set := consonants
+		switch {
+		case i == 0:
+			set = leading
+		case i%2 == 1:
+			set = vowels
+		}
+		b.WriteRune(set[cryptoRandInt(len(set))])
+		entropy += math.Log2(float64(len(set)))
+	}
+	for i := 0; i < digits; i++ {
+		b.WriteRune(digitSet[cryptoRandInt(len(digitSet))])
+		entropy += math.Log2(float64(len(digitSet)))
+	}
+
+	return b.String(), math.Round(entropy*100) / 100
+}
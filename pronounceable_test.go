@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratePronounceable(t *testing.T) {
+	s, entropy := GeneratePronounceable(10, StringOptions{Pronounceable: true, Capitalize: true, Digits: 2})
+	assert.Len(t, s, 10)
+	assert.Equal(t, strings.ToUpper(s[:1]), s[:1], "First letter should be capitalized")
+	for i, r := range strings.ToLower(s[:8]) {
+		if i%2 == 0 {
+			assert.Contains(t, pronounceableConsonants, string(r))
+		} else {
+			assert.Contains(t, pronounceableVowels, string(r))
+		}
+	}
+	assert.Equal(t, "", strings.Trim(s[8:], "0123456789"), "String should end with digits")
+
+	want := 4*math.Log2(16) + 4*math.Log2(5) + 2*math.Log2(10)
+	assert.InDelta(t, want, entropy, 0.01, "Entropy should reflect the restricted alphabets")
+	assert.Less(t, entropy, 10*math.Log2(62), "Entropy should be lower than uniform alphanumeric")
+}
+
+func TestGeneratePronounceableCapitalizedUnambiguous(t *testing.T) {
+	opts := StringOptions{Pronounceable: true, Capitalize: true, Unambiguous: true}
+	for i := 0; i < 2000; i++ {
+		s, _ := GeneratePronounceable(8, opts)
+		if !assert.False(t, strings.ContainsAny(s, AmbiguousChars), "%q should not contain ambiguous characters", s) {
+			return
+		}
+		assert.Equal(t, strings.ToUpper(s[:1]), s[:1], "First letter should be capitalized")
+	}
+
+	// 15 capital consonants (S removed), then 15 consonants (l removed) and 5 vowels
+	_, entropy := GeneratePronounceable(4, opts)
+	assert.InDelta(t, math.Log2(15)+math.Log2(5)+math.Log2(15)+math.Log2(5), entropy, 0.01)
+}
+
+func TestGenerateStringsPronounceable(t *testing.T) {
+	r := setupRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/json?a=12&pronounceable=1&digits=3", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.AlphaNumeric.String, 12)
+	assert.Greater(t, response.AlphaNumeric.Entropy, 0.0, "Response should report the real entropy")
+
+	for _, query := range []string{"digits=2", "pronounceable=1&preset=hex"} {
+		req = httptest.NewRequest(http.MethodGet, "/json?"+query, nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Query %q should be rejected", query)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	DefaultQRSize = 256
+	MinQRSize     = 64
+	MaxQRSize     = 2048
+)
+
+// qrRecoveryLevels maps the ec query parameter to the QR error-correction level
+var qrRecoveryLevels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,     // ~7% of codewords can be restored
+	"M": qrcode.Medium,  // ~15%
+	"Q": qrcode.High,    // ~25%
+	"H": qrcode.Highest, // ~30%
+}
+
+// qrFormat reports whether format= asks for a QR code and returns the image kind, png or svg
+func qrFormat(c *gin.Context) (string, bool) {
+	kind, ok := strings.CutPrefix(strings.ToLower(c.Query("format")), "qr.")
+	return kind, ok
+}
+
+// EncodeQR encodes content as a QR code with the given error-correction level
+// (L, M, Q or H) and renders it as a PNG of size pixels or as an SVG.
+func EncodeQR(content, kind, level string, size int) ([]byte, string, error) {
+	recovery, ok := qrRecoveryLevels[strings.ToUpper(level)]
+	if !ok {
+		return nil, "", fmt.Errorf("ec must be one of L, M, Q, H, got %q", level)
+	}
+	if kind != "png" && kind != "svg" {
+		return nil, "", fmt.Errorf("format must be qr.png or qr.svg, got %q", "qr."+kind)
+	}
+	q, err := qrcode.New(content, recovery)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot encode QR code: %v", err)
+	}
+	if kind == "svg" {
+		return renderQRSVG(q.Bitmap(), size), "image/svg+xml", nil
+	}
+	b, err := q.PNG(size)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot render QR code: %v", err)
+	}
+	return b, "image/png", nil
+}
+
+// renderQRSVG draws the module bitmap (including its quiet zone) as one SVG path,
+// merging horizontal runs of dark modules into single rectangles.
+func renderQRSVG(bitmap [][]bool, size int) []byte {
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x := 0; x < len(row); {
+			if !row[x] {
+				x++
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+	n := len(bitmap)
+	return []byte(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="%s"/></svg>`+"\n",
+		size, size, n, n, n, n, path.String()))
+}
+
+// respondQR writes content as a QR image of the given kind using the ec and size query parameters
+func respondQR(c *gin.Context, content, kind string) {
+	size, ok, err := queryInt(c, "size", MinQRSize, MaxQRSize)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !ok {
+		size = DefaultQRSize
+	}
+	b, contentType, err := EncodeQR(content, kind, c.DefaultQuery("ec", "M"), size)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store, no-cache, must-revalidate")
+	c.Data(http.StatusOK, contentType, b)
+}
+
+// qrContent picks the generated value selected by field= for a QR response from /json
+func qrContent(c *gin.Context, response Response) (string, error) {
+	switch field := strings.ToLower(c.DefaultQuery("field", "printable")); field {
+	case "printable":
+		return response.Printable.String, nil
+	case "alphanumeric":
+		return response.AlphaNumeric.String, nil
+	case "mask":
+		if response.Mask == nil {
+			return "", fmt.Errorf("field=mask requires a mask parameter")
+		}
+		return response.Mask.String, nil
+	case "passphrase":
+		opts, err := parsePassphraseOptions(c)
+		if err != nil {
+			return "", err
+		}
+		return GeneratePassphrase(opts).String, nil
+	default:
+		return "", fmt.Errorf("field must be one of printable, alphanumeric, mask, passphrase, got %q", field)
+	}
+}
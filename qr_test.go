@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQRPNG(t *testing.T) {
+	r := setupRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/json?format=qr.png&field=alphanumeric&a=40&ec=H&size=300", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+
+	img, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+	if assert.NoError(t, err, "Body should be a valid PNG") {
+		assert.Equal(t, 300, img.Bounds().Dx())
+		assert.Equal(t, 300, img.Bounds().Dy())
+	}
+}
+
+func TestQRSVG(t *testing.T) {
+	r := setupRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/totp?format=qr.svg&issuer=Acme&account=bot", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/svg+xml", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.True(t, strings.HasPrefix(body, "<svg"), "Body should be an SVG document")
+	assert.Contains(t, body, `<path fill="#000" d="M`)
+
+	bitmap := [][]bool{{true, true, false}, {false, true, true}, {false, false, false}}
+	assert.Contains(t, string(renderQRSVG(bitmap, 90)), `d="M0 0h2v1h-2zM1 1h2v1h-2z"`, "Runs of dark modules should be merged")
+
+	for _, query := range []string{"format=qr.gif", "format=qr.png&ec=X", "format=qr.png&size=10", "format=qr.png&field=mask", "format=qr.png&field=uuid"} {
+		req = httptest.NewRequest(http.MethodGet, "/json?"+query, nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Query %q should be rejected", query)
+	}
+}
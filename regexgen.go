@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxRegexPatternLength = 500
+	maxRegexOutputLength  = 1000
+	// DefaultRegexRepeatCap bounds *, + and open-ended {n,} repetitions
+	DefaultRegexRepeatCap = 8
+	maxRegexRepeatCap     = 100
+	// maxRegexRepeatProduct bounds the product of nested repetition counts
+	maxRegexRepeatProduct = 100000
+	// maxRegexSteps bounds the syntax tree nodes visited to generate one string
+	maxRegexSteps = 1000000
+)
+
+var (
+	// errRegexTooLong is returned when a match would exceed maxRegexOutputLength characters
+	errRegexTooLong = fmt.Errorf("generated string exceeds %d characters; tighten the pattern", maxRegexOutputLength)
+	// errRegexTooComplex is returned when generating a match exceeds the step budget
+	errRegexTooComplex = fmt.Errorf("pattern needs more than %d steps to generate; tighten the pattern", maxRegexSteps)
+)
+
+// RegexResponse is the JSON payload returned by /regex
+type RegexResponse struct {
+	Pattern string         `json:"pattern"`
+	Strings []RandomString `json:"strings"`
+}
+
+// regexGenerator produces random strings matching a parsed RE2 pattern
+type regexGenerator struct {
+	re         *syntax.Regexp
+	repeatCap  int
+	stepBudget int // nodes Generate may visit, maxRegexSteps unless overridden
+	steps      int
+}
+
+// NewRegexGenerator parses pattern with RE2 (Perl) syntax and checks that it only
+// uses the supported subset: literals, classes, groups, alternation, repetition and anchors.
+func NewRegexGenerator(pattern string, repeatCap int) (*regexGenerator, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("pattern must not be empty")
+	}
+	if len(pattern) > maxRegexPatternLength {
+		return nil, fmt.Errorf("pattern must be at most %d bytes", maxRegexPatternLength)
+	}
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %v", err)
+	}
+	if err := checkRegexSupported(re, true); err != nil {
+		return nil, err
+	}
+	dropEmptyRepeats(re)
+	if err := checkRegexRepeats(re, repeatCap, 1); err != nil {
+		return nil, err
+	}
+	return &regexGenerator{re: re, repeatCap: repeatCap, stepBudget: maxRegexSteps}, nil
+}
+
+// checkRegexSupported rejects operators that cannot be generated directly. Anchors
+// (^, $, \A, \z) generate no output, so they are only accepted where that is
+// correct: as the first or last element of the top-level concatenation (anchorOK).
+func checkRegexSupported(re *syntax.Regexp, anchorOK bool) error {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return fmt.Errorf("pattern can never match")
+	case syntax.OpCharClass:
+		if len(re.Rune) == 0 {
+			return fmt.Errorf("pattern contains an empty character class")
+		}
+	case syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return fmt.Errorf("word boundaries (\\b, \\B) are not supported")
+	case syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText:
+		if !anchorOK {
+			return fmt.Errorf("anchors (^, $, \\A, \\z) are only supported at the start or end of the pattern")
+		}
+	}
+	for i, sub := range re.Sub {
+		edge := anchorOK && re.Op == syntax.OpConcat && (i == 0 || i == len(re.Sub)-1)
+		if err := checkRegexSupported(sub, edge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropEmptyRepeats replaces every repetition whose expression can only match the
+// empty string (or that repeats at most zero times) with an empty match, so nested
+// groups such as ((()*)*)* cost nothing to generate. It reports whether re itself
+// can only match the empty string.
+func dropEmptyRepeats(re *syntax.Regexp) bool {
+	empty := true
+	for _, sub := range re.Sub {
+		if !dropEmptyRepeats(sub) {
+			empty = false
+		}
+	}
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText:
+		return true
+	case syntax.OpCapture, syntax.OpConcat, syntax.OpAlternate:
+		return empty
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		if empty || (re.Op == syntax.OpRepeat && re.Max == 0) {
+			*re = syntax.Regexp{Op: syntax.OpEmptyMatch}
+			return true
+		}
+	}
+	return false
+}
+
+// checkRegexRepeats rejects patterns whose nested repetitions multiply to more than
+// maxRegexRepeatProduct, counting open-ended repetitions as min + repeatCap
+func checkRegexRepeats(re *syntax.Regexp, repeatCap, product int) error {
+	switch re.Op {
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		_, max := repeatRange(re, repeatCap)
+		if product *= max; product > maxRegexRepeatProduct {
+			return fmt.Errorf("nested repetitions allow more than %d repeats; tighten the pattern or lower cap", maxRegexRepeatProduct)
+		}
+	}
+	for _, sub := range re.Sub {
+		if err := checkRegexRepeats(sub, repeatCap, product); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// repeatRange returns how often a repetition operator repeats its expression,
+// capping open-ended repetitions at min + repeatCap
+func repeatRange(re *syntax.Regexp, repeatCap int) (int, int) {
+	min, max := re.Min, re.Max
+	switch re.Op {
+	case syntax.OpStar:
+		min, max = 0, -1
+	case syntax.OpPlus:
+		min, max = 1, -1
+	case syntax.OpQuest:
+		min, max = 0, 1
+	}
+	if max < 0 {
+		max = min + repeatCap
+	}
+	return min, max
+}
+
+// Generate returns one random string matching the pattern
+func (g *regexGenerator) Generate() (string, error) {
+	var b strings.Builder
+	g.steps = 0
+	if err := g.gen(&b, g.re); err != nil {
+		return "", err
+	}
+	if utf8.RuneCountInString(b.String()) > maxRegexOutputLength {
+		return "", errRegexTooLong
+	}
+	return b.String(), nil
+}
+
+// gen appends a random match of re to b, choosing every branch with cryptoRandInt
+func (g *regexGenerator) gen(b *strings.Builder, re *syntax.Regexp) error {
+	if err := g.step(b); err != nil {
+		return err
+	}
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText:
+		return nil
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			if re.Flags&syntax.FoldCase != 0 && cryptoRandInt(2) == 1 {
+				r = unicode.SimpleFold(r)
+			}
+			b.WriteRune(r)
+		}
+		return nil
+	case syntax.OpCharClass:
+		b.WriteRune(randomRuneFromRanges(re.Rune))
+		return nil
+	case syntax.OpAnyCharNotNL, syntax.OpAnyChar:
+		b.WriteRune(rune(0x20 + cryptoRandInt(0x7f-0x20)))
+		return nil
+	case syntax.OpCapture:
+		return g.gen(b, re.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if err := g.gen(b, sub); err != nil {
+				return err
+			}
+		}
+		return nil
+	case syntax.OpAlternate:
+		return g.gen(b, re.Sub[cryptoRandInt(len(re.Sub))])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		return g.genRepeat(b, re)
+	default:
+		return fmt.Errorf("unsupported regular expression operator %v", re.Op)
+	}
+}
+
+// step counts one visited node and stops generation once the output or step budget is exceeded
+func (g *regexGenerator) step(b *strings.Builder) error {
+	if b.Len() > maxRegexOutputLength*utf8.UTFMax {
+		return errRegexTooLong
+	}
+	if g.steps++; g.steps > g.stepBudget {
+		return errRegexTooComplex
+	}
+	return nil
+}
+
+// genRepeat appends between min and max matches of the repeated expression,
+// capping open-ended repetitions at min + repeatCap.
+func (g *regexGenerator) genRepeat(b *strings.Builder, re *syntax.Regexp) error {
+	min, max := repeatRange(re, g.repeatCap)
+	n := min + cryptoRandInt(max-min+1)
+	for i := 0; i < n; i++ {
+		if err := g.gen(b, re.Sub[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// randomRuneFromRanges picks a rune from a class given as [lo, hi] pairs. Printable
+// ASCII members are preferred so negated classes such as [^0-9] stay readable;
+// otherwise every member of the class is equally likely.
+func randomRuneFromRanges(ranges []rune) rune {
+	if printable := intersectRanges(ranges, 0x20, 0x7e); len(printable) > 0 {
+		ranges = printable
+	}
+	total := 0
+	for i := 0; i < len(ranges); i += 2 {
+		total += int(ranges[i+1]-ranges[i]) + 1
+	}
+	pick := cryptoRandInt(total)
+	for i := 0; i < len(ranges); i += 2 {
+		size := int(ranges[i+1]-ranges[i]) + 1
+		if pick < size {
+			return ranges[i] + rune(pick)
+		}
+		pick -= size
+	}
+	return ranges[0]
+}
+
+// intersectRanges clips each [lo, hi] pair of ranges to [min, max]
+func intersectRanges(ranges []rune, min, max rune) []rune {
+	var out []rune
+	for i := 0; i < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		if lo < min {
+			lo = min
+		}
+		if hi > max {
+			hi = max
+		}
+		if lo <= hi {
+			out = append(out, lo, hi)
+		}
+	}
+	return out
+}
+
+// regexHandler handles GET /regex?pattern=&count=&cap=
+func regexHandler(c *gin.Context) {
+	repeatCap, ok, err := queryInt(c, "cap", 0, maxRegexRepeatCap)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !ok {
+		repeatCap = DefaultRegexRepeatCap
+	}
+	count, ok, err := queryInt(c, "count", 1, MaxBatchCount)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !ok {
+		count = 1
+	}
+	pattern := c.Query("pattern")
+	g, err := NewRegexGenerator(pattern, repeatCap)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	results := make([]RandomString, count)
+	lines := make([]string, count)
+	for i := range results {
+		s, err := g.Generate()
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		results[i] = RandomString{Length: utf8.RuneCountInString(s), String: s}
+		lines[i] = s
+	}
+	respondList(c, lines, RegexResponse{Pattern: pattern, Strings: results})
+}
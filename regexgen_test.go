@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegexGenerator(t *testing.T) {
+	patterns := []string{
+		`^[A-Z]{3}-\d{4}$`,
+		`(foo|bar|baz)+_[a-f0-9]{8}`,
+		`[^0-9]{5}`,
+		`(?i)hello`,
+		`\w+@example\.(com|org)`,
+		`a*b?c{2,}`,
+		`.{10}`,
+		`\Aabc\z`,
+		`^`,
+	}
+	for _, pattern := range patterns {
+		g, err := NewRegexGenerator(pattern, DefaultRegexRepeatCap)
+		if !assert.NoError(t, err, "Pattern %q should be supported", pattern) {
+			continue
+		}
+		re := regexp.MustCompile(`^(?:` + pattern + `)$`)
+		for i := 0; i < 50; i++ {
+			s, err := g.Generate()
+			assert.NoError(t, err)
+			assert.True(t, re.MatchString(s), "%q should match %q", s, pattern)
+		}
+	}
+
+	for _, pattern := range []string{"", `\bword`, `(unclosed`, `[^\x00-\x{10FFFF}]`, `a^b$c`, `a\Ab`, `(^a)b`, `^a|b$`, `(?m)a$b`} {
+		_, err := NewRegexGenerator(pattern, DefaultRegexRepeatCap)
+		assert.Error(t, err, "Pattern %q should be rejected", pattern)
+	}
+
+	g, err := NewRegexGenerator(`a{999}b{999}`, DefaultRegexRepeatCap)
+	assert.NoError(t, err)
+	_, err = g.Generate()
+	assert.Error(t, err, "Oversized output should be rejected")
+}
+
+func TestRegexEndpoint(t *testing.T) {
+	r := setupRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/regex?count=3&pattern="+url.QueryEscape(`[A-Z]{2}\d+`)+"&cap=3", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp RegexResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp.Strings, 3)
+	for _, s := range resp.Strings {
+		assert.Regexp(t, regexp.MustCompile(`^[A-Z]{2}\d{1,4}$`), s.String, "Unbounded + should be capped")
+	}
+}
+
+func TestRegexNestedRepeatsAreBounded(t *testing.T) {
+	r := setupRouter()
+
+	tests := []struct {
+		query string
+		code  int
+	}{
+		{"count=100&pattern=" + url.QueryEscape(`(((((((((((()*)*)*)*)*)*)*)*)*)*)*)*`), http.StatusOK},
+		{"count=100&cap=100&pattern=" + url.QueryEscape(`((((((((()*)*)*)*)*)*)*)*)*`), http.StatusOK},
+		{"count=100&pattern=" + url.QueryEscape(`(?:(?:(?:){1000}){1000}){1000}`), http.StatusBadRequest},
+		{"count=100&pattern=" + url.QueryEscape(`(?:(?:(?:a{0}){1000}){1000}){1000}`), http.StatusBadRequest},
+		{"count=100&cap=100&pattern=" + url.QueryEscape(`((((a*)*)*)*)*`), http.StatusBadRequest},
+		{"count=100&pattern=" + url.QueryEscape(`(?:(?:(?:a?){50}){50}){50}`), http.StatusBadRequest},
+		{"count=100&pattern=" + url.QueryEscape(`(?:(?:(?:a?){10}){10}){10}`), http.StatusOK},
+	}
+	for _, tt := range tests {
+		done := make(chan int, 1)
+		go func() {
+			req := httptest.NewRequest(http.MethodGet, "/regex?"+tt.query, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			done <- w.Code
+		}()
+		select {
+		case code := <-done:
+			assert.Equal(t, tt.code, code, "Query %q", tt.query)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Query %q did not finish within the deadline", tt.query)
+		}
+	}
+
+	g, err := NewRegexGenerator(`[a-z]{100}`, DefaultRegexRepeatCap)
+	assert.NoError(t, err)
+	g.stepBudget = 50
+	_, err = g.Generate()
+	assert.Equal(t, errRegexTooComplex, err, "Generation should stop once the step budget is spent")
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxListBodyBytes = 1 << 20
+	maxListItems     = 10000
+)
+
+// ListResponse is the JSON payload returned by /shuffle and /pick
+type ListResponse struct {
+	Count int               `json:"count"`
+	Items []json.RawMessage `json:"items"`
+}
+
+// listRequest is the object form of a /shuffle or /pick body
+type listRequest struct {
+	Items   []json.RawMessage `json:"items"`
+	Weights []float64         `json:"weights"`
+}
+
+// readList parses the request body as a JSON array, a JSON object with items and
+// weights, or newline-separated text. Weights may also be passed as weights=1,2,3.
+func readList(c *gin.Context) ([]json.RawMessage, []float64, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxListBodyBytes))
+	if err != nil {
+		return nil, nil, fmt.Errorf("request body must be at most %d bytes", maxListBodyBytes)
+	}
+	req, err := parseListBody(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if val, ok := c.GetQuery("weights"); ok {
+		if req.Weights != nil {
+			return nil, nil, fmt.Errorf("weights given both in the body and the query string")
+		}
+		if req.Weights, err = parseWeights(val); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := validateList(&req); err != nil {
+		return nil, nil, err
+	}
+	return req.Items, req.Weights, nil
+}
+
+// parseListBody decodes a JSON array, a JSON object or newline-separated text
+func parseListBody(body []byte) (listRequest, error) {
+	var req listRequest
+	trimmed := bytes.TrimSpace(body)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		if err := json.Unmarshal(trimmed, &req.Items); err != nil {
+			return req, fmt.Errorf("invalid JSON array: %v", err)
+		}
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		if err := json.Unmarshal(trimmed, &req); err != nil {
+			return req, fmt.Errorf("invalid JSON object: %v", err)
+		}
+	default:
+		for _, line := range strings.Split(string(trimmed), "\n") {
+			if line = strings.TrimRight(line, "\r"); strings.TrimSpace(line) == "" {
+				continue
+			}
+			item, _ := json.Marshal(line)
+			req.Items = append(req.Items, item)
+		}
+	}
+	return req, nil
+}
+
+// parseWeights parses the comma-separated weights= query parameter
+func parseWeights(val string) ([]float64, error) {
+	var weights []float64
+	for _, field := range strings.Split(val, ",") {
+		w, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight %q", field)
+		}
+		weights = append(weights, w)
+	}
+	return weights, nil
+}
+
+// validateList checks the item count and weights and compacts JSON items in place
+func validateList(req *listRequest) error {
+	if len(req.Items) == 0 {
+		return fmt.Errorf("list must contain at least one item")
+	}
+	if len(req.Items) > maxListItems {
+		return fmt.Errorf("list may contain at most %d items", maxListItems)
+	}
+	for i, item := range req.Items {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, item); err == nil {
+			req.Items[i] = buf.Bytes()
+		}
+	}
+	return validateWeights(req.Weights, len(req.Items))
+}
+
+// validateWeights checks there is one finite, non-negative weight per item and a positive total
+func validateWeights(weights []float64, n int) error {
+	if weights == nil {
+		return nil
+	}
+	if len(weights) != n {
+		return fmt.Errorf("got %d weights for %d items", len(weights), n)
+	}
+	total := 0.0
+	for _, w := range weights {
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return fmt.Errorf("weights must be finite and non-negative")
+		}
+		total += w
+	}
+	if total <= 0 || math.IsInf(total, 0) {
+		return fmt.Errorf("weights must have a positive finite sum")
+	}
+	return nil
+}
+
+// PickIndexes returns k indexes of an n-item list in random order. Without weights
+// this is a uniform sample without replacement (a partial Fisher-Yates shuffle via
+// randomPositions); with weights each draw picks a remaining item with probability
+// proportional to its weight.
+func PickIndexes(n, k int, weights []float64) ([]int, error) {
+	if k > n {
+		return nil, fmt.Errorf("cannot pick %d of %d items", k, n)
+	}
+	if weights == nil {
+		return randomPositions(n, k), nil
+	}
+
+	remaining := make([]int, 0, n)
+	for i, w := range weights {
+		if w > 0 {
+			remaining = append(remaining, i)
+		}
+	}
+	if k > len(remaining) {
+		return nil, fmt.Errorf("cannot pick %d items when only %d have a positive weight", k, len(remaining))
+	}
+
+	picked := make([]int, 0, k)
+	for len(picked) < k {
+		// Recompute the total each draw so rounding errors do not accumulate
+		total := 0.0
+		for _, idx := range remaining {
+			total += weights[idx]
+		}
+		target := cryptoRandFloat64() * total
+		j := len(remaining) - 1 // guards against rounding at the top of the range
+		for pos, idx := range remaining {
+			if target < weights[idx] {
+				j = pos
+				break
+			}
+			target -= weights[idx]
+		}
+		idx := remaining[j]
+		picked = append(picked, idx)
+		remaining = append(remaining[:j], remaining[j+1:]...)
+	}
+	return picked, nil
+}
+
+// respondPicked writes the selected items as JSON, or one per line for plain-text clients
+func respondPicked(c *gin.Context, items []json.RawMessage, idx []int) {
+	picked := make([]json.RawMessage, len(idx))
+	lines := make([]string, len(idx))
+	for i, j := range idx {
+		picked[i] = items[j]
+		var s string
+		if json.Unmarshal(items[j], &s) == nil {
+			lines[i] = s
+		} else {
+			lines[i] = string(items[j])
+		}
+	}
+	respondList(c, lines, ListResponse{Count: len(picked), Items: picked})
+}
+
+// shuffleHandler handles POST /shuffle
+func shuffleHandler(c *gin.Context) {
+	items, weights, err := readList(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondPicked(c, items, ShuffleIndexes(len(items), weights))
+}
+
+// ShuffleIndexes returns a random permutation of n indexes. Without weights it is a
+// uniform Fisher-Yates shuffle; with weights, items with a positive weight are
+// ordered by successive weighted draws and zero-weight items follow in uniform order.
+func ShuffleIndexes(n int, weights []float64) []int {
+	if weights == nil {
+		return randomPositions(n, n)
+	}
+	var positive, zero []int
+	for i, w := range weights {
+		if w > 0 {
+			positive = append(positive, i)
+		} else {
+			zero = append(zero, i)
+		}
+	}
+	order, _ := PickIndexes(n, len(positive), weights)
+	for _, j := range randomPositions(len(zero), len(zero)) {
+		order = append(order, zero[j])
+	}
+	return order
+}
+
+// pickHandler handles POST /pick?n=
+func pickHandler(c *gin.Context) {
+	items, weights, err := readList(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	n, ok, err := queryInt(c, "n", 1, maxListItems)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !ok {
+		n = 1
+	}
+	idx, err := PickIndexes(len(items), n, weights)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondPicked(c, items, idx)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPickIndexesWeighted(t *testing.T) {
+	weights := []float64{0, 1, 3}
+	counts := make([]int, 3)
+	for i := 0; i < 4000; i++ {
+		idx, err := PickIndexes(3, 1, weights)
+		assert.NoError(t, err)
+		counts[idx[0]]++
+	}
+	assert.Equal(t, 0, counts[0], "Zero-weight items should never be picked")
+	assert.InDelta(t, 3000, counts[2], 150, "Items should be picked proportionally to their weight")
+
+	_, err := PickIndexes(3, 3, weights)
+	assert.Error(t, err, "Cannot pick more items than have a positive weight")
+}
+
+func TestShuffleIndexes(t *testing.T) {
+	assert.ElementsMatch(t, []int{0, 1, 2, 3, 4}, ShuffleIndexes(5, nil))
+	order := ShuffleIndexes(4, []float64{1, 0, 2, 0})
+	assert.ElementsMatch(t, []int{0, 2}, order[:2], "Positive weights should come first")
+	assert.ElementsMatch(t, []int{1, 3}, order[2:], "Zero weights should come last")
+}
+
+func TestShuffleEndpoint(t *testing.T) {
+	r := setupRouter()
+
+	req := httptest.NewRequest(http.MethodPost, "/shuffle", strings.NewReader(`["alice", "bob", 3, {"name": "carol"}]`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp ListResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 4, resp.Count)
+	got := make([]string, len(resp.Items))
+	for i, item := range resp.Items {
+		var buf bytes.Buffer
+		assert.NoError(t, json.Compact(&buf, item))
+		got[i] = buf.String()
+	}
+	assert.ElementsMatch(t, []string{`"alice"`, `"bob"`, `3`, `{"name":"carol"}`}, got)
+
+	req = httptest.NewRequest(http.MethodPost, "/pick?n=2", strings.NewReader("alice\nbob\n\ncarol\n"))
+	req.Header.Set("User-Agent", "curl/8.0.1")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Len(t, lines, 2)
+	for _, line := range lines {
+		assert.Contains(t, []string{"alice", "bob", "carol"}, line)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/pick?n=1", strings.NewReader(`{"items": ["a", "b"], "weights": [0, 1]}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, `"b"`, string(resp.Items[0]), "Only the weighted item can be picked")
+
+	for _, body := range []string{"", `{"items": ["a"], "weights": [1, 2]}`, `["a", "b"`} {
+		req = httptest.NewRequest(http.MethodPost, "/pick", strings.NewReader(body))
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Body %q should be rejected", body)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/ssh"
+)
+
+const maxSSHCommentLength = 100
+
+// SSHKeyResponse is the JSON payload returned by /sshkey
+type SSHKeyResponse struct {
+	Type        string      `json:"type"`
+	Comment     string      `json:"comment,omitempty"`
+	PrivateKey  string      `json:"private_key"`
+	PublicKey   string      `json:"public_key"`
+	Fingerprint string      `json:"fingerprint"`
+	Passphrase  *Passphrase `json:"passphrase,omitempty"`
+}
+
+// NewSSHKey generates an ed25519 key pair and returns the OpenSSH private key, the
+// authorized_keys line and the SHA256 fingerprint. A non-empty passphrase encrypts
+// the private key with aes256-ctr and bcrypt_pbkdf, as ssh-keygen does.
+func NewSSHKey(comment, passphrase string) (SSHKeyResponse, error) {
+	priv := ed25519.NewKeyFromSeed(cryptoRandBytes(ed25519.SeedSize))
+	pub, err := ssh.NewPublicKey(priv.Public())
+	if err != nil {
+		return SSHKeyResponse{}, err
+	}
+
+	var block *pem.Block
+	if passphrase == "" {
+		block, err = ssh.MarshalPrivateKey(priv, comment)
+	} else {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(priv, comment, []byte(passphrase))
+	}
+	if err != nil {
+		return SSHKeyResponse{}, fmt.Errorf("cannot encode private key: %v", err)
+	}
+
+	authorized := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(pub)), "\n")
+	if comment != "" {
+		authorized += " " + comment
+	}
+	return SSHKeyResponse{
+		Type:        ssh.KeyAlgoED25519,
+		Comment:     comment,
+		PrivateKey:  string(pem.EncodeToMemory(block)),
+		PublicKey:   authorized,
+		Fingerprint: ssh.FingerprintSHA256(pub),
+	}, nil
+}
+
+// parseSSHComment validates the comment= query parameter for the authorized_keys line
+func parseSSHComment(c *gin.Context) (string, error) {
+	comment := c.Query("comment")
+	if len(comment) > maxSSHCommentLength {
+		return "", fmt.Errorf("comment must be at most %d bytes", maxSSHCommentLength)
+	}
+	for _, r := range comment {
+		if !unicode.IsPrint(r) {
+			return "", fmt.Errorf("comment contains non-printable character %U", r)
+		}
+	}
+	return comment, nil
+}
+
+// sshKeyHandler handles GET /sshkey?comment=&encrypt=1 (plus the /passphrase options)
+func sshKeyHandler(c *gin.Context) {
+	comment, err := parseSSHComment(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	encrypt, err := queryBool(c, "encrypt")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if !encrypt {
+		for _, key := range passphraseParams {
+			if _, ok := c.GetQuery(key); ok {
+				respondError(c, fmt.Errorf("%s only applies to the passphrase and requires encrypt=1", key))
+				return
+			}
+		}
+	}
+
+	var passphrase *Passphrase
+	secret := ""
+	if encrypt {
+		opts, err := parsePassphraseOptions(c)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		generated := GeneratePassphrase(opts)
+		passphrase, secret = &generated, generated.String
+	}
+	resp, err := NewSSHKey(comment, secret)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	resp.Passphrase = passphrase
+
+	lines := []string{strings.TrimSuffix(resp.PrivateKey, "\n"), resp.PublicKey, resp.Fingerprint}
+	if passphrase != nil {
+		lines = append(lines, passphrase.String)
+	}
+	respondList(c, lines, resp)
+}
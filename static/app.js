@@ -17,13 +17,34 @@ function refreshStrings() {
         alphanumericLength = 1;
         document.getElementById("a").value = 1;
     }
-    var url = "/json?p=" + printableLength + "&a=" + alphanumericLength;
+    // Carry over any extra options (charset, ...) from the page URL
+    var params = new URLSearchParams(window.location.search);
+    params.delete("_");
+    params.set("p", printableLength);
+    params.set("a", alphanumericLength);
+    var mask = document.getElementById("mask").value;
+    if (mask) {
+        params.set("mask", mask);
+    } else {
+        params.delete("mask");
+    }
+    if (document.getElementById("unambiguous").checked) {
+        params.set("unambiguous", "1");
+    } else {
+        params.delete("unambiguous");
+    }
+    var url = "/json?" + params.toString();
 
     fetch(url, { cache: 'no-store' })
         .then(response => response.json())
         .then(data => {
+            if (data.error) {
+                console.error('Generation failed:', data.error);
+                return;
+            }
             document.getElementById("printable-string").textContent = data.printable.string;
             document.getElementById("alphanumeric-string").textContent = data.alphanumeric.string;
+            document.getElementById("mask-string").textContent = data.mask ? data.mask.string : "";
         });
 }
 
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultTOTPSecretBytes = 20
+	MinTOTPSecretBytes     = 10
+	MaxTOTPSecretBytes     = 64
+	DefaultTOTPDigits      = 6
+	DefaultTOTPPeriod      = 30
+	DefaultTOTPIssuer      = "random"
+	DefaultTOTPAccount     = "user"
+	maxTOTPLabelLength     = 100
+)
+
+// totpAlgorithms maps the otpauth algorithm names to their HMAC hash
+var totpAlgorithms = map[string]func() hash.Hash{
+	"SHA1":   sha1.New,
+	"SHA256": sha256.New,
+	"SHA512": sha512.New,
+}
+
+// totpSecretEncoding is unpadded RFC 4648 base32, the form authenticator apps expect
+var totpSecretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTPResponse is the JSON payload returned by /totp
+type TOTPResponse struct {
+	Secret    string  `json:"secret"`
+	URI       string  `json:"uri"`
+	Issuer    string  `json:"issuer"`
+	Account   string  `json:"account"`
+	Algorithm string  `json:"algorithm"`
+	Digits    int     `json:"digits"`
+	Period    int     `json:"period"`
+	Code      string  `json:"code"`
+	ValidFor  int     `json:"valid_for"`
+	Entropy   float64 `json:"entropy_bits"`
+}
+
+// TOTPConfig describes an RFC 6238 time-based one-time password credential
+type TOTPConfig struct {
+	Secret    []byte
+	Issuer    string
+	Account   string
+	Algorithm string
+	Digits    int
+	Period    int
+}
+
+// NewTOTPConfig returns a credential with a fresh secret of n random bytes
+func NewTOTPConfig(n int, issuer, account, algorithm string, digits, period int) (TOTPConfig, error) {
+	if n < MinTOTPSecretBytes || n > MaxTOTPSecretBytes {
+		return TOTPConfig{}, fmt.Errorf("bytes must be between %d and %d", MinTOTPSecretBytes, MaxTOTPSecretBytes)
+	}
+	if err := checkTOTPLabel("issuer", issuer); err != nil {
+		return TOTPConfig{}, err
+	}
+	if err := checkTOTPLabel("account", account); err != nil {
+		return TOTPConfig{}, err
+	}
+	algorithm = strings.ToUpper(algorithm)
+	if _, ok := totpAlgorithms[algorithm]; !ok {
+		return TOTPConfig{}, fmt.Errorf("algorithm must be one of SHA1, SHA256, SHA512, got %q", algorithm)
+	}
+	if digits != 6 && digits != 8 {
+		return TOTPConfig{}, fmt.Errorf("digits must be 6 or 8")
+	}
+	return TOTPConfig{
+		Secret:    cryptoRandBytes(n),
+		Issuer:    issuer,
+		Account:   account,
+		Algorithm: algorithm,
+		Digits:    digits,
+		Period:    period,
+	}, nil
+}
+
+// checkTOTPLabel validates one half of the issuer:account label
+func checkTOTPLabel(name, label string) error {
+	switch {
+	case label == "":
+		return fmt.Errorf("%s must not be empty", name)
+	case len(label) > maxTOTPLabelLength:
+		return fmt.Errorf("%s must be at most %d bytes", name, maxTOTPLabelLength)
+	case strings.Contains(label, ":"):
+		return fmt.Errorf("%s must not contain ':'", name)
+	}
+	return nil
+}
+
+// EncodedSecret returns the secret as unpadded base32
+func (t TOTPConfig) EncodedSecret() string {
+	return totpSecretEncoding.EncodeToString(t.Secret)
+}
+
+// URI returns the otpauth://totp key URI understood by authenticator apps. The Key
+// URI Format expects percent-encoding throughout, so spaces in the issuer parameter
+// are written as %20 rather than the + that url.Values would produce.
+func (t TOTPConfig) URI() string {
+	label := url.PathEscape(t.Issuer) + ":" + url.PathEscape(t.Account)
+	return "otpauth://totp/" + label +
+		"?algorithm=" + t.Algorithm +
+		"&digits=" + strconv.Itoa(t.Digits) +
+		"&issuer=" + totpQueryEscape(t.Issuer) +
+		"&period=" + strconv.Itoa(t.Period) +
+		"&secret=" + t.EncodedSecret()
+}
+
+// totpQueryEscape percent-encodes s for a query value, encoding spaces as %20
+func totpQueryEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// Code returns the one-time password for the time step containing at
+func (t TOTPConfig) Code(at time.Time) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(at.Unix()/int64(t.Period)))
+	mac := hmac.New(totpAlgorithms[t.Algorithm], t.Secret)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation (RFC 4226 section 5.3)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < t.Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", t.Digits, value%mod)
+}
+
+// parseTOTPOptions builds a fresh credential from the /totp query parameters
+func parseTOTPOptions(c *gin.Context) (TOTPConfig, error) {
+	n, ok, err := queryInt(c, "bytes", MinTOTPSecretBytes, MaxTOTPSecretBytes)
+	if err != nil {
+		return TOTPConfig{}, err
+	}
+	if !ok {
+		n = DefaultTOTPSecretBytes
+	}
+	digits, ok, err := queryInt(c, "digits", 6, 8)
+	if err != nil {
+		return TOTPConfig{}, err
+	}
+	if !ok {
+		digits = DefaultTOTPDigits
+	}
+	period, ok, err := queryInt(c, "period", 15, 300)
+	if err != nil {
+		return TOTPConfig{}, err
+	}
+	if !ok {
+		period = DefaultTOTPPeriod
+	}
+	return NewTOTPConfig(n,
+		c.DefaultQuery("issuer", DefaultTOTPIssuer),
+		c.DefaultQuery("account", DefaultTOTPAccount),
+		c.DefaultQuery("algorithm", "SHA1"),
+		digits, period)
+}
+
+// totpHandler handles GET /totp?issuer=&account=&bytes=20&digits=6&period=30&algorithm=SHA1
+func totpHandler(c *gin.Context) {
+	t, err := parseTOTPOptions(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if kind, ok := qrFormat(c); ok {
+		respondQR(c, t.URI(), kind)
+		return
+	}
+	now := time.Now()
+	resp := TOTPResponse{
+		Secret:    t.EncodedSecret(),
+		URI:       t.URI(),
+		Issuer:    t.Issuer,
+		Account:   t.Account,
+		Algorithm: t.Algorithm,
+		Digits:    t.Digits,
+		Period:    t.Period,
+		Code:      t.Code(now),
+		ValidFor:  t.Period - int(now.Unix()%int64(t.Period)),
+		Entropy:   float64(len(t.Secret) * 8),
+	}
+	respondList(c, []string{resp.Secret, resp.URI, resp.Code}, resp)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// Test vectors from RFC 6238 Appendix B
+	secrets := map[string]string{
+		"SHA1":   "12345678901234567890",
+		"SHA256": "12345678901234567890123456789012",
+		"SHA512": "1234567890123456789012345678901234567890123456789012345678901234",
+	}
+	vectors := []struct {
+		unix      int64
+		algorithm string
+		code      string
+	}{
+		{59, "SHA1", "94287082"},
+		{59, "SHA256", "46119246"},
+		{59, "SHA512", "90693936"},
+		{1111111109, "SHA1", "07081804"},
+		{1234567890, "SHA256", "91819424"},
+		{20000000000, "SHA512", "47863826"},
+	}
+	for _, v := range vectors {
+		cfg := TOTPConfig{Secret: []byte(secrets[v.algorithm]), Algorithm: v.algorithm, Digits: 8, Period: 30}
+		assert.Equal(t, v.code, cfg.Code(time.Unix(v.unix, 0)), "%s at %d", v.algorithm, v.unix)
+	}
+}
+
+func TestTOTPEndpoint(t *testing.T) {
+	r := setupRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/totp?issuer=Acme%20Corp&account=ci@acme.test&bytes=32&digits=8&period=60", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp TOTPResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	secret, err := totpSecretEncoding.DecodeString(resp.Secret)
+	assert.NoError(t, err)
+	assert.Len(t, secret, 32)
+	assert.Len(t, resp.Code, 8)
+	assert.Equal(t, 256.0, resp.Entropy)
+
+	assert.Equal(t, "otpauth://totp/Acme%20Corp:ci@acme.test?algorithm=SHA1&digits=8&issuer=Acme%20Corp&period=60&secret="+resp.Secret, resp.URI,
+		"Spaces should be percent-encoded in both the label and the issuer parameter")
+	u, err := url.Parse(resp.URI)
+	assert.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/Acme Corp:ci@acme.test", u.Path)
+	assert.Equal(t, resp.Secret, u.Query().Get("secret"))
+	assert.Equal(t, "Acme Corp", u.Query().Get("issuer"))
+	assert.Equal(t, "8", u.Query().Get("digits"))
+	assert.Equal(t, "60", u.Query().Get("period"))
+	cfg := TOTPConfig{Issuer: "R&D + Ops", Account: "ci", Algorithm: "SHA1", Digits: 6, Period: 30}
+	assert.Contains(t, cfg.URI(), "&issuer=R%26D%20%2B%20Ops&", "Reserved characters in the issuer should be percent-encoded")
+
+	for _, query := range []string{"bytes=8", "digits=7", "period=5", "algorithm=MD5", "issuer=a:b", "account="} {
+		req = httptest.NewRequest(http.MethodGet, "/totp?"+query, nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Query %q should be rejected", query)
+	}
+}
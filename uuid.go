@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UUIDResponse is the JSON payload returned by /uuid
+type UUIDResponse struct {
+	Version int      `json:"version"`
+	Count   int      `json:"count"`
+	UUIDs   []string `json:"uuids"`
+}
+
+// uuidV7State keeps the last timestamp and counter so v7 UUIDs generated by this
+// process are strictly increasing, even within the same millisecond.
+var uuidV7State struct {
+	sync.Mutex
+	ms      uint64
+	counter uint16
+}
+
+// NewUUIDv4 returns a random (version 4) UUID
+func NewUUIDv4() [16]byte {
+	var u [16]byte
+	copy(u[:], cryptoRandBytes(16))
+	u[6] = (u[6] & 0x0f) | 0x40 // version 4
+	u[8] = (u[8] & 0x3f) | 0x80 // RFC 9562 variant
+	return u
+}
+
+// NewUUIDv7 returns a time-ordered (version 7) UUID. The 12-bit rand_a field is
+// used as a counter (RFC 9562 section 6.2, method 1): it starts at a random value
+// with the top bit clear and is incremented for UUIDs within the same millisecond.
+// On overflow the timestamp is advanced by one millisecond.
+func NewUUIDv7() [16]byte {
+	random := cryptoRandBytes(10)
+	now := uint64(time.Now().UnixMilli())
+
+	uuidV7State.Lock()
+	ms, counter := now, binary.BigEndian.Uint16(random[:2])&0x7ff
+	if now <= uuidV7State.ms {
+		ms, counter = uuidV7State.ms, uuidV7State.counter+1
+		if counter > 0xfff {
+			ms, counter = ms+1, 0
+		}
+	}
+	uuidV7State.ms, uuidV7State.counter = ms, counter
+	uuidV7State.Unlock()
+
+	var u [16]byte
+	binary.BigEndian.PutUint64(u[0:8], ms<<16)
+	u[6] = 0x70 | byte(counter>>8) // version 7
+	u[7] = byte(counter)
+	copy(u[8:], random[2:])
+	u[8] = (u[8] & 0x3f) | 0x80 // RFC 9562 variant
+	return u
+}
+
+// FormatUUID renders u in the canonical 8-4-4-4-12 form
+func FormatUUID(u [16]byte) string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+// generateUUIDs handles GET /uuid?version=4|7&count=N
+func generateUUIDs(c *gin.Context) {
+	version := 4
+	if val, ok := c.GetQuery("version"); ok {
+		v, err := strconv.Atoi(val)
+		if err != nil || (v != 4 && v != 7) {
+			respondError(c, fmt.Errorf("version must be 4 or 7, got %q", val))
+			return
+		}
+		version = v
+	}
+	count, ok, err := queryInt(c, "count", 1, MaxBatchCount)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !ok {
+		count = 1
+	}
+
+	uuids := make([]string, count)
+	for i := range uuids {
+		if version == 7 {
+			uuids[i] = FormatUUID(NewUUIDv7())
+		} else {
+			uuids[i] = FormatUUID(NewUUIDv4())
+		}
+	}
+	respondList(c, uuids, UUIDResponse{Version: version, Count: count, UUIDs: uuids})
+}
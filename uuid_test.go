@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-([47])[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNewUUIDv4(t *testing.T) {
+	s := FormatUUID(NewUUIDv4())
+	m := uuidPattern.FindStringSubmatch(s)
+	assert.NotNil(t, m, "UUID %s should be well formed", s)
+	assert.Equal(t, "4", m[1])
+}
+
+func TestNewUUIDv7Monotonic(t *testing.T) {
+	prev := FormatUUID(NewUUIDv7())
+	for i := 0; i < 10000; i++ {
+		next := FormatUUID(NewUUIDv7())
+		m := uuidPattern.FindStringSubmatch(next)
+		assert.NotNil(t, m, "UUID %s should be well formed", next)
+		assert.Equal(t, "7", m[1])
+		if !assert.Less(t, prev, next, "v7 UUIDs should be strictly increasing") {
+			return
+		}
+		prev = next
+	}
+}
+
+func TestUUIDEndpoint(t *testing.T) {
+	r := setupRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/uuid?version=7&count=5", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp UUIDResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 7, resp.Version)
+	assert.Len(t, resp.UUIDs, 5)
+
+	req = httptest.NewRequest(http.MethodGet, "/uuid?count=3", nil)
+	req.Header.Set("User-Agent", "curl/8.0.1")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Len(t, lines, 3, "CLI clients should get one UUID per line")
+
+	req = httptest.NewRequest(http.MethodGet, "/uuid?version=5", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
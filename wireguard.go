@@ -0,0 +1,164 @@
+package main
+
+import (
+	"crypto/ecdh"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// wireGuardKeySize is the size of WireGuard private, public and preshared keys
+	wireGuardKeySize = 32
+	// maxWireGuardHostLength is the longest endpoint host name (RFC 1123)
+	maxWireGuardHostLength = 253
+)
+
+// wireGuardHostPattern accepts RFC 1123 host names such as vpn.example.com
+var wireGuardHostPattern = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?\.)*[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
+
+// WireGuardResponse is the JSON payload returned by /wireguard
+type WireGuardResponse struct {
+	PrivateKey   string `json:"private_key"`
+	PublicKey    string `json:"public_key"`
+	PresharedKey string `json:"preshared_key,omitempty"`
+	Config       string `json:"config,omitempty"`
+}
+
+// WireGuardPeer holds the optional settings of the rendered [Peer] section
+type WireGuardPeer struct {
+	AllowedIPs []string
+	Endpoint   string
+	Keepalive  int
+}
+
+// NewWireGuardPrivateKey returns 32 random bytes clamped as a curve25519 scalar, like wg genkey
+func NewWireGuardPrivateKey() []byte {
+	key := cryptoRandBytes(wireGuardKeySize)
+	key[0] &= 248
+	key[31] &= 127
+	key[31] |= 64
+	return key
+}
+
+// WireGuardPublicKey derives the X25519 public key for a private key, like wg pubkey
+func WireGuardPublicKey(private []byte) ([]byte, error) {
+	key, err := ecdh.X25519().NewPrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	return key.PublicKey().Bytes(), nil
+}
+
+// RenderWireGuardPeer returns a [Peer] section that adds publicKey to another host's config
+func RenderWireGuardPeer(publicKey, presharedKey string, peer WireGuardPeer) string {
+	var b strings.Builder
+	b.WriteString("[Peer]\n")
+	fmt.Fprintf(&b, "PublicKey = %s\n", publicKey)
+	if presharedKey != "" {
+		fmt.Fprintf(&b, "PresharedKey = %s\n", presharedKey)
+	}
+	fmt.Fprintf(&b, "AllowedIPs = %s\n", strings.Join(peer.AllowedIPs, ", "))
+	if peer.Endpoint != "" {
+		fmt.Fprintf(&b, "Endpoint = %s\n", peer.Endpoint)
+	}
+	if peer.Keepalive > 0 {
+		fmt.Fprintf(&b, "PersistentKeepalive = %d\n", peer.Keepalive)
+	}
+	return b.String()
+}
+
+// parseWireGuardPeer validates the allowed_ips, endpoint and keepalive query parameters
+func parseWireGuardPeer(c *gin.Context) (WireGuardPeer, error) {
+	var peer WireGuardPeer
+	var err error
+	allowed := c.Query("allowed_ips")
+	if allowed == "" {
+		return peer, fmt.Errorf("config=1 requires allowed_ips, e.g. 10.0.0.2/32")
+	}
+	for _, field := range strings.Split(allowed, ",") {
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(field))
+		if err != nil {
+			return peer, fmt.Errorf("invalid allowed_ips entry %q", field)
+		}
+		peer.AllowedIPs = append(peer.AllowedIPs, prefix.String())
+	}
+	if endpoint := c.Query("endpoint"); endpoint != "" {
+		if peer.Endpoint, err = parseWireGuardEndpoint(endpoint); err != nil {
+			return peer, err
+		}
+	}
+	if peer.Keepalive, _, err = queryInt(c, "keepalive", 0, 65535); err != nil {
+		return peer, err
+	}
+	return peer, nil
+}
+
+// parseWireGuardEndpoint checks that endpoint is host:port with an IP address or RFC 1123
+// host name, so nothing but the endpoint can end up on the rendered Endpoint line
+func parseWireGuardEndpoint(endpoint string) (string, error) {
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("endpoint must be host:port, got %q", endpoint)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && addr.Zone() == "" {
+		host = addr.String()
+	} else if len(host) > maxWireGuardHostLength || !wireGuardHostPattern.MatchString(host) {
+		return "", fmt.Errorf("endpoint host must be an IP address or host name, got %q", host)
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 || strconv.Itoa(n) != port {
+		return "", fmt.Errorf("endpoint port must be between 1 and 65535, got %q", port)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// wireGuardHandler handles GET /wireguard?psk=1&config=1&allowed_ips=&endpoint=&keepalive=
+func wireGuardHandler(c *gin.Context) {
+	withPSK, err := queryBool(c, "psk")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	withConfig, err := queryBool(c, "config")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	var peer WireGuardPeer
+	if withConfig {
+		if peer, err = parseWireGuardPeer(c); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+
+	private := NewWireGuardPrivateKey()
+	public, err := WireGuardPublicKey(private)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	resp := WireGuardResponse{
+		PrivateKey: base64.StdEncoding.EncodeToString(private),
+		PublicKey:  base64.StdEncoding.EncodeToString(public),
+	}
+	if withPSK {
+		resp.PresharedKey = base64.StdEncoding.EncodeToString(cryptoRandBytes(wireGuardKeySize))
+	}
+	lines := []string{resp.PrivateKey, resp.PublicKey}
+	if resp.PresharedKey != "" {
+		lines = append(lines, resp.PresharedKey)
+	}
+	if withConfig {
+		resp.Config = RenderWireGuardPeer(resp.PublicKey, resp.PresharedKey, peer)
+		lines = append(lines, strings.TrimSuffix(resp.Config, "\n"))
+	}
+	respondList(c, lines, resp)
+}
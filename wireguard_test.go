@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWireGuardKeys(t *testing.T) {
+	// RFC 7748 section 6.1 test vector
+	private, _ := hex.DecodeString("77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a")
+	public, err := WireGuardPublicKey(private)
+	assert.NoError(t, err)
+	assert.Equal(t, "8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a", hex.EncodeToString(public))
+
+	for i := 0; i < 100; i++ {
+		key := NewWireGuardPrivateKey()
+		assert.Len(t, key, 32)
+		assert.Zero(t, key[0]&7, "Low three bits should be cleared")
+		assert.Equal(t, byte(64), key[31]&192, "Top bit should be cleared and bit 254 set")
+	}
+}
+
+func TestWireGuardEndpoint(t *testing.T) {
+	r := setupRouter()
+
+	tests := []struct {
+		query string
+		psk   bool
+		peer  string // expected config after the PublicKey and PresharedKey lines
+	}{
+		{"", false, ""},
+		{"psk=1", true, ""},
+		{"config=1&allowed_ips=10.0.0.2/32", false, "AllowedIPs = 10.0.0.2/32\n"},
+		{"psk=1&config=1&allowed_ips=10.0.0.2/32,fd00::2/128&endpoint=vpn.example.com:51820&keepalive=25", true,
+			"AllowedIPs = 10.0.0.2/32, fd00::2/128\nEndpoint = vpn.example.com:51820\nPersistentKeepalive = 25\n"},
+		{"config=1&allowed_ips=0.0.0.0/0&endpoint=%5B2001:db8::1%5D:51820", false,
+			"AllowedIPs = 0.0.0.0/0\nEndpoint = [2001:db8::1]:51820\n"},
+	}
+	for _, tt := range tests {
+		w := serveRequest(r, http.MethodGet, "/wireguard?"+tt.query, nil)
+		if !assert.Equal(t, http.StatusOK, w.Code, "Query %q", tt.query) {
+			continue
+		}
+		var resp WireGuardResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		private, err := base64.StdEncoding.DecodeString(resp.PrivateKey)
+		assert.NoError(t, err)
+		public, err := WireGuardPublicKey(private)
+		assert.NoError(t, err)
+		assert.Equal(t, base64.StdEncoding.EncodeToString(public), resp.PublicKey, "Public key should match the private key")
+
+		config := "[Peer]\nPublicKey = " + resp.PublicKey + "\n"
+		if tt.psk {
+			psk, err := base64.StdEncoding.DecodeString(resp.PresharedKey)
+			assert.NoError(t, err)
+			assert.Len(t, psk, wireGuardKeySize)
+			config += "PresharedKey = " + resp.PresharedKey + "\n"
+		} else {
+			assert.Empty(t, resp.PresharedKey)
+		}
+		if tt.peer == "" {
+			assert.Empty(t, resp.Config, "Query %q", tt.query)
+		} else {
+			assert.Equal(t, config+tt.peer, resp.Config, "Query %q", tt.query)
+		}
+	}
+
+	for _, query := range []string{
+		"psk=2", "config=1", "config=1&allowed_ips=10.0.0.300/32", "config=1&allowed_ips=10.0.0.2/32&keepalive=-1",
+		"config=1&allowed_ips=10.0.0.2/32&endpoint=vpn",
+		"config=1&allowed_ips=10.0.0.2/32&endpoint=vpn:0",
+		"config=1&allowed_ips=10.0.0.2/32&endpoint=vpn:+51820",
+		"config=1&allowed_ips=10.0.0.2/32&endpoint=:51820",
+		"config=1&allowed_ips=10.0.0.2/32&endpoint=vpn%0APostUp%20%3D%20touch%20/tmp/x:51820",
+		"config=1&allowed_ips=10.0.0.2/32&endpoint=vpn%20example.com:51820",
+		"config=1&allowed_ips=10.0.0.2/32&endpoint=-vpn.example.com:51820",
+		"config=1&allowed_ips=10.0.0.2/32&endpoint=%5Bfe80::1%25wg0%0APostUp%5D:51820",
+	} {
+		w := serveRequest(r, http.MethodGet, "/wireguard?"+query, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Query %q should be rejected", query)
+	}
+}